@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/neticdk-k8s/k8s-inventory-client/collect"
+	"github.com/neticdk-k8s/k8s-inventory-client/config"
+	kubernetes "github.com/neticdk-k8s/k8s-inventory-client/kubernetes"
+	"github.com/neticdk-k8s/k8s-inventory-client/manifest"
+	"github.com/rs/zerolog/log"
+)
+
+// runCollect implements the collect subcommand. It collects the inventory
+// once and writes it to a file or stdout. The exit code is non-zero if the
+// inventory could not be written or the collection did not succeed.
+func runCollect(cfg config.Config, args []string) int {
+	fs := flag.NewFlagSet("collect", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s collect [flags]\n\nCollect the inventory once and write it as JSON.\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	kubeconfig := fs.String("kubeconfig", "", "path to the kubeconfig file (default $KUBECONFIG, in-cluster or ~/.kube/config)")
+	kubeContext := fs.String("context", "", "kubeconfig context to use (default current context)")
+	manifests := fs.String("manifests", "", "collect from a manifest, directory or tarball of manifests instead of a cluster")
+	output := fs.String("output", "-", "file to write the inventory to, - for stdout")
+	gzipped := fs.Bool("gzip", false, "gzip the inventory")
+	sign := fs.Bool("sign", false, "sign the inventory as a JWS using --tls-crt and --tls-key")
+	fs.StringVar(&cfg.TLSCrt, "tls-crt", cfg.TLSCrt, "PEM certificate file to sign with")
+	fs.StringVar(&cfg.TLSKey, "tls-key", cfg.TLSKey, "PEM key file to sign with")
+	fs.StringVar(&cfg.Impersonate, "impersonate", cfg.Impersonate, "user to impersonate")
+	fs.StringVar(&cfg.EnabledCollectors, "enabled-collectors", cfg.EnabledCollectors, "comma separated list of collectors to run (default all)")
+	fs.StringVar(&cfg.DisabledCollectors, "disabled-collectors", cfg.DisabledCollectors, "comma separated list of collectors not to run")
+	fs.StringVar(&cfg.CollectTimeout, "timeout", cfg.CollectTimeout, "how long a single collector may run")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg.AuthEnabled = *sign
+	cfg.UploadInventory = false
+	cfg.CacheEnabled = false
+	cfg.SpoolDir = ""
+	// Nothing is served, so there is no one to compare or watch snapshots
+	cfg.HistorySize = 0
+	cfg.WatchBufferSize = 0
+	collection := collect.NewInventoryCollection(cfg)
+	if *sign && !collection.SigningEnabled() {
+		log.Error().Msg("signing requested but no usable certificate and key")
+		return 1
+	}
+
+	var i *collect.Inventory
+	if *manifests != "" {
+		objs, err := manifest.Load(*manifests)
+		if err != nil {
+			log.Error().Err(err).Msg("loading manifests")
+			return 1
+		}
+		i = collection.CollectOffline(context.Background(), objs)
+	} else {
+		cs, client, err := kubernetes.CreateK8SClientFromKubeconfig(*kubeconfig, *kubeContext, cfg.Impersonate)
+		if err != nil {
+			log.Error().Err(err).Msg("creating clientset")
+			return 1
+		}
+		i = collection.CollectOnce(context.Background(), cs, client)
+	}
+
+	if err := writeInventory(collection, *output, *gzipped); err != nil {
+		log.Error().Err(err).Msg("writing inventory")
+		return 1
+	}
+
+	if !i.CollectionSucceeded {
+		log.Error().Strs("errors", i.CollectionErrors).Msg("collection did not succeed")
+		return 1
+	}
+	return 0
+}
+
+func writeInventory(collection *collect.InventoryCollection, output string, gzipped bool) error {
+	if output == "-" {
+		return collection.WriteInventory(os.Stdout, gzipped)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := collection.WriteInventory(f, gzipped); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,394 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	admissionv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	Register(NewCollector("admission", nil, collectAdmission))
+}
+
+// WebhookConfiguration is a MutatingWebhookConfiguration or a
+// ValidatingWebhookConfiguration
+type WebhookConfiguration struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Webhooks             []Webhook
+}
+
+type Webhook struct {
+	Name           string
+	FailurePolicy  string
+	MatchPolicy    string
+	SideEffects    string
+	TimeoutSeconds int32
+	// ReinvocationPolicy is only set for mutating webhooks
+	ReinvocationPolicy string `json:",omitempty"`
+	// Service is set unless the webhook is called by URL
+	Service           *WebhookService `json:",omitempty"`
+	URL               string          `json:",omitempty"`
+	NamespaceSelector *inventory.LabelSelector
+	ObjectSelector    *inventory.LabelSelector
+	Rules             []AdmissionRule
+	// MatchConditions are the CEL expressions requests must match
+	MatchConditions []string `json:",omitempty"`
+	CABundle        []CertificateInfo
+	// CABundleExpiry is when the first certificate of CABundle expires
+	CABundleExpiry *metav1.Time `json:",omitempty"`
+}
+
+type WebhookService struct {
+	Namespace string
+	Name      string
+	Path      string `json:",omitempty"`
+	Port      int32
+}
+
+// AdmissionRule selects the requests an admission webhook or policy applies
+// to
+type AdmissionRule struct {
+	Operations    []string
+	APIGroups     []string
+	APIVersions   []string
+	Resources     []string
+	ResourceNames []string `json:",omitempty"`
+	Scope         string
+}
+
+type ValidatingAdmissionPolicy struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 ValidatingAdmissionPolicySpec   `json:"spec"`
+	Status               ValidatingAdmissionPolicyStatus `json:"status"`
+}
+
+type ValidatingAdmissionPolicySpec struct {
+	FailurePolicy string
+	// ParamKind is given as apiVersion/kind
+	ParamKind        string `json:",omitempty"`
+	MatchConstraints *AdmissionMatch
+	Validations      []PolicyValidation
+	MatchConditions  []string `json:",omitempty"`
+}
+
+// AdmissionMatch selects the resources a policy or binding applies to
+type AdmissionMatch struct {
+	NamespaceSelector    *inventory.LabelSelector
+	ObjectSelector       *inventory.LabelSelector
+	ResourceRules        []AdmissionRule
+	ExcludeResourceRules []AdmissionRule `json:",omitempty"`
+	MatchPolicy          string
+}
+
+type PolicyValidation struct {
+	Expression string
+	Message    string `json:",omitempty"`
+	Reason     string `json:",omitempty"`
+}
+
+type ValidatingAdmissionPolicyStatus struct {
+	ObservedGeneration int64
+	// TypeWarnings are the warnings from type checking the expressions
+	TypeWarnings []string `json:",omitempty"`
+	Conditions   []Condition
+}
+
+type ValidatingAdmissionPolicyBinding struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 ValidatingAdmissionPolicyBindingSpec `json:"spec"`
+}
+
+type ValidatingAdmissionPolicyBindingSpec struct {
+	PolicyName string
+	ParamRef   *PolicyParamRef `json:",omitempty"`
+	// MatchResources narrows the resources matched by the policy
+	MatchResources *AdmissionMatch `json:",omitempty"`
+	// ValidationActions are Deny, Warn or Audit
+	ValidationActions []string
+}
+
+type PolicyParamRef struct {
+	Name                    string                   `json:",omitempty"`
+	Namespace               string                   `json:",omitempty"`
+	Selector                *inventory.LabelSelector `json:",omitempty"`
+	ParameterNotFoundAction string                   `json:",omitempty"`
+}
+
+func NewWebhookConfiguration(kind, resourceType string) *WebhookConfiguration {
+	return &WebhookConfiguration{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     admissionv1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: resourceType,
+		},
+	}
+}
+
+func NewValidatingAdmissionPolicy(apiVersion string) *ValidatingAdmissionPolicy {
+	return &ValidatingAdmissionPolicy{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "ValidatingAdmissionPolicy",
+			APIGroup:     admissionv1.GroupName,
+			APIVersion:   apiVersion,
+			ResourceType: "validatingadmissionpolicies",
+		},
+	}
+}
+
+func NewValidatingAdmissionPolicyBinding(apiVersion string) *ValidatingAdmissionPolicyBinding {
+	return &ValidatingAdmissionPolicyBinding{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "ValidatingAdmissionPolicyBinding",
+			APIGroup:     admissionv1.GroupName,
+			APIVersion:   apiVersion,
+			ResourceType: "validatingadmissionpolicybindings",
+		},
+	}
+}
+
+func collectAdmission(ctx context.Context, cl *Clients, i *Inventory) error {
+	mutating, mutatingErr := collectMutatingWebhooks(ctx, cl.Client)
+	i.Admission.MutatingWebhookConfigurations = mutating
+	validating, validatingErr := collectValidatingWebhooks(ctx, cl.Client)
+	i.Admission.ValidatingWebhookConfigurations = validating
+
+	// ValidatingAdmissionPolicies are read as v1 if served, otherwise as
+	// v1beta1 which has the same fields
+	var policiesErr error
+	i.Admission.ValidatingAdmissionPolicies = make([]*ValidatingAdmissionPolicy, 0)
+	i.Admission.ValidatingAdmissionPolicyBindings = make([]*ValidatingAdmissionPolicyBinding, 0)
+	switch {
+	case cl.APIResources["admissionregistration.k8s.io/v1/validatingadmissionpolicies"]:
+		policiesErr = collectAdmissionPolicies(ctx, cl.Client, i, "v1", &admissionv1.ValidatingAdmissionPolicyList{}, &admissionv1.ValidatingAdmissionPolicyBindingList{})
+	case cl.APIResources["admissionregistration.k8s.io/v1beta1/validatingadmissionpolicies"]:
+		policiesErr = collectAdmissionPolicies(ctx, cl.Client, i, "v1beta1", &admissionv1beta1.ValidatingAdmissionPolicyList{}, &admissionv1beta1.ValidatingAdmissionPolicyBindingList{})
+	}
+	return errors.Join(mutatingErr, validatingErr, policiesErr)
+}
+
+func collectMutatingWebhooks(ctx context.Context, kc client.Client) ([]*WebhookConfiguration, error) {
+	configs := make([]*WebhookConfiguration, 0)
+	configList := &admissionv1.MutatingWebhookConfigurationList{}
+	if err := kc.List(ctx, configList); err != nil {
+		return nil, fmt.Errorf("getting MutatingWebhookConfigurations: %v", err)
+	}
+	for _, o := range configList.Items {
+		r := NewWebhookConfiguration("MutatingWebhookConfiguration", "mutatingwebhookconfigurations")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Webhooks = make([]Webhook, 0, len(o.Webhooks))
+		for _, w := range o.Webhooks {
+			hook := webhook(w.Name, w.ClientConfig, w.Rules, w.FailurePolicy, w.MatchPolicy, w.SideEffects, w.TimeoutSeconds, w.NamespaceSelector, w.ObjectSelector, w.MatchConditions)
+			hook.ReinvocationPolicy = string(admissionv1.NeverReinvocationPolicy)
+			if w.ReinvocationPolicy != nil {
+				hook.ReinvocationPolicy = string(*w.ReinvocationPolicy)
+			}
+			r.Webhooks = append(r.Webhooks, hook)
+		}
+		configs = append(configs, r)
+	}
+	return configs, nil
+}
+
+func collectValidatingWebhooks(ctx context.Context, kc client.Client) ([]*WebhookConfiguration, error) {
+	configs := make([]*WebhookConfiguration, 0)
+	configList := &admissionv1.ValidatingWebhookConfigurationList{}
+	if err := kc.List(ctx, configList); err != nil {
+		return nil, fmt.Errorf("getting ValidatingWebhookConfigurations: %v", err)
+	}
+	for _, o := range configList.Items {
+		r := NewWebhookConfiguration("ValidatingWebhookConfiguration", "validatingwebhookconfigurations")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Webhooks = make([]Webhook, 0, len(o.Webhooks))
+		for _, w := range o.Webhooks {
+			r.Webhooks = append(r.Webhooks, webhook(w.Name, w.ClientConfig, w.Rules, w.FailurePolicy, w.MatchPolicy, w.SideEffects, w.TimeoutSeconds, w.NamespaceSelector, w.ObjectSelector, w.MatchConditions))
+		}
+		configs = append(configs, r)
+	}
+	return configs, nil
+}
+
+// webhook records the fields shared by mutating and validating webhooks with
+// the defaults of the API server filled in
+func webhook(name string, cc admissionv1.WebhookClientConfig, rules []admissionv1.RuleWithOperations, failurePolicy *admissionv1.FailurePolicyType, matchPolicy *admissionv1.MatchPolicyType, sideEffects *admissionv1.SideEffectClass, timeout *int32, namespaceSelector, objectSelector *metav1.LabelSelector, matchConditions []admissionv1.MatchCondition) Webhook {
+	r := Webhook{
+		Name:              name,
+		FailurePolicy:     string(admissionv1.Fail),
+		MatchPolicy:       string(admissionv1.Equivalent),
+		TimeoutSeconds:    10,
+		NamespaceSelector: labelSelector(namespaceSelector),
+		ObjectSelector:    labelSelector(objectSelector),
+		Rules:             make([]AdmissionRule, 0, len(rules)),
+		CABundle:          parseCertificates(cc.CABundle),
+	}
+	if failurePolicy != nil {
+		r.FailurePolicy = string(*failurePolicy)
+	}
+	if matchPolicy != nil {
+		r.MatchPolicy = string(*matchPolicy)
+	}
+	if sideEffects != nil {
+		r.SideEffects = string(*sideEffects)
+	}
+	if timeout != nil {
+		r.TimeoutSeconds = *timeout
+	}
+	if cc.Service != nil {
+		r.Service = &WebhookService{Namespace: cc.Service.Namespace, Name: cc.Service.Name, Port: 443}
+		if cc.Service.Path != nil {
+			r.Service.Path = *cc.Service.Path
+		}
+		if cc.Service.Port != nil {
+			r.Service.Port = *cc.Service.Port
+		}
+	}
+	if cc.URL != nil {
+		r.URL = *cc.URL
+	}
+	for _, rule := range rules {
+		r.Rules = append(r.Rules, admissionRule(rule, nil))
+	}
+	for _, c := range matchConditions {
+		r.MatchConditions = append(r.MatchConditions, c.Expression)
+	}
+	for _, c := range r.CABundle {
+		if r.CABundleExpiry == nil || c.NotAfter.Before(r.CABundleExpiry) {
+			expiry := c.NotAfter
+			r.CABundleExpiry = &expiry
+		}
+	}
+	return r
+}
+
+func admissionRule(rule admissionv1.RuleWithOperations, resourceNames []string) AdmissionRule {
+	r := AdmissionRule{
+		Operations:    make([]string, 0, len(rule.Operations)),
+		APIGroups:     rule.APIGroups,
+		APIVersions:   rule.APIVersions,
+		Resources:     rule.Resources,
+		ResourceNames: resourceNames,
+		Scope:         string(admissionv1.AllScopes),
+	}
+	for _, op := range rule.Operations {
+		r.Operations = append(r.Operations, string(op))
+	}
+	if rule.Scope != nil {
+		r.Scope = string(*rule.Scope)
+	}
+	return r
+}
+
+// collectAdmissionPolicies reads policies and bindings of apiVersion into
+// the given lists and records them as v1 objects
+func collectAdmissionPolicies(ctx context.Context, kc client.Client, i *Inventory, apiVersion string, policyList, bindingList client.ObjectList) error {
+	var errs []error
+	policies := &admissionv1.ValidatingAdmissionPolicyList{}
+	if err := kc.List(ctx, policyList); err != nil {
+		errs = append(errs, fmt.Errorf("getting ValidatingAdmissionPolicies: %v", err))
+	} else if err := convertObject(policyList, policies); err != nil {
+		errs = append(errs, fmt.Errorf("converting ValidatingAdmissionPolicies: %v", err))
+	}
+	for _, o := range policies.Items {
+		i.Admission.ValidatingAdmissionPolicies = append(i.Admission.ValidatingAdmissionPolicies, collectAdmissionPolicy(o, apiVersion))
+	}
+
+	bindings := &admissionv1.ValidatingAdmissionPolicyBindingList{}
+	if err := kc.List(ctx, bindingList); err != nil {
+		errs = append(errs, fmt.Errorf("getting ValidatingAdmissionPolicyBindings: %v", err))
+	} else if err := convertObject(bindingList, bindings); err != nil {
+		errs = append(errs, fmt.Errorf("converting ValidatingAdmissionPolicyBindings: %v", err))
+	}
+	for _, o := range bindings.Items {
+		r := NewValidatingAdmissionPolicyBinding(apiVersion)
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec.PolicyName = o.Spec.PolicyName
+		if p := o.Spec.ParamRef; p != nil {
+			r.Spec.ParamRef = &PolicyParamRef{Name: p.Name, Namespace: p.Namespace, Selector: labelSelector(p.Selector)}
+			if p.ParameterNotFoundAction != nil {
+				r.Spec.ParamRef.ParameterNotFoundAction = string(*p.ParameterNotFoundAction)
+			}
+		}
+		r.Spec.MatchResources = admissionMatch(o.Spec.MatchResources)
+		r.Spec.ValidationActions = make([]string, 0, len(o.Spec.ValidationActions))
+		for _, a := range o.Spec.ValidationActions {
+			r.Spec.ValidationActions = append(r.Spec.ValidationActions, string(a))
+		}
+		i.Admission.ValidatingAdmissionPolicyBindings = append(i.Admission.ValidatingAdmissionPolicyBindings, r)
+	}
+	return errors.Join(errs...)
+}
+
+func collectAdmissionPolicy(o admissionv1.ValidatingAdmissionPolicy, apiVersion string) *ValidatingAdmissionPolicy {
+	r := NewValidatingAdmissionPolicy(apiVersion)
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+	r.Spec.FailurePolicy = string(admissionv1.Fail)
+	if o.Spec.FailurePolicy != nil {
+		r.Spec.FailurePolicy = string(*o.Spec.FailurePolicy)
+	}
+	if p := o.Spec.ParamKind; p != nil {
+		r.Spec.ParamKind = p.APIVersion + "/" + p.Kind
+	}
+	r.Spec.MatchConstraints = admissionMatch(o.Spec.MatchConstraints)
+	r.Spec.Validations = make([]PolicyValidation, 0, len(o.Spec.Validations))
+	for _, v := range o.Spec.Validations {
+		pv := PolicyValidation{Expression: v.Expression, Message: v.Message}
+		if v.Reason != nil {
+			pv.Reason = string(*v.Reason)
+		}
+		r.Spec.Validations = append(r.Spec.Validations, pv)
+	}
+	for _, c := range o.Spec.MatchConditions {
+		r.Spec.MatchConditions = append(r.Spec.MatchConditions, c.Expression)
+	}
+
+	r.Status.ObservedGeneration = o.Status.ObservedGeneration
+	if o.Status.TypeChecking != nil {
+		for _, w := range o.Status.TypeChecking.ExpressionWarnings {
+			r.Status.TypeWarnings = append(r.Status.TypeWarnings, w.FieldRef+": "+w.Warning)
+		}
+	}
+	r.Status.Conditions = conditions(o.Status.Conditions)
+	return r
+}
+
+func admissionMatch(m *admissionv1.MatchResources) *AdmissionMatch {
+	if m == nil {
+		return nil
+	}
+	r := &AdmissionMatch{
+		NamespaceSelector: labelSelector(m.NamespaceSelector),
+		ObjectSelector:    labelSelector(m.ObjectSelector),
+		ResourceRules:     make([]AdmissionRule, 0, len(m.ResourceRules)),
+		MatchPolicy:       string(admissionv1.Equivalent),
+	}
+	for _, rule := range m.ResourceRules {
+		r.ResourceRules = append(r.ResourceRules, admissionRule(rule.RuleWithOperations, rule.ResourceNames))
+	}
+	for _, rule := range m.ExcludeResourceRules {
+		r.ExcludeResourceRules = append(r.ExcludeResourceRules, admissionRule(rule.RuleWithOperations, rule.ResourceNames))
+	}
+	if m.MatchPolicy != nil {
+		r.MatchPolicy = string(*m.MatchPolicy)
+	}
+	return r
+}
+
+// convertObject copies from into to, which must have the same fields, e.g.
+// the same type of another API version
+func convertObject(from, to any) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+	if err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u, to)
+}
@@ -0,0 +1,308 @@
+package collect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Default and maximum page size of the query API
+const (
+	defaultAPILimit = 500
+	maxAPILimit     = 5000
+)
+
+// apiSection is a part of the inventory served by the query API
+type apiSection struct {
+	// collector fills the section
+	collector string
+	get       func(i *Inventory) any
+}
+
+// apiSections maps the list endpoints of the query API to the part of the
+// inventory they serve
+var apiSections = map[string]apiSection{
+	"namespaces":                                  {"namespace", func(i *Inventory) any { return i.Namespaces }},
+	"priorityclasses":                             {"priority_class", func(i *Inventory) any { return i.PriorityClasses }},
+	"nodes":                                       {"node", func(i *Inventory) any { return i.Nodes }},
+	"workloads":                                   {"workload", func(i *Inventory) any { return i.Workloads }},
+	"networkpolicies":                             {"network_policy", func(i *Inventory) any { return i.NetworkPolicies }},
+	"storage/persistentvolumes":                   {"storage", func(i *Inventory) any { return i.Storage.PersistentVolumes }},
+	"storage/storageclasses":                      {"storage", func(i *Inventory) any { return i.Storage.StorageClasses }},
+	"storage/persistentvolumeclaims":              {"storage", func(i *Inventory) any { return i.Storage.PersistentVolumeClaims }},
+	"storage/reclaimable":                         {"storage", func(i *Inventory) any { return i.Storage.Reclaimable }},
+	"storage/csidrivers":                          {"csi", func(i *Inventory) any { return i.Storage.CSIDrivers }},
+	"storage/csinodes":                            {"csi", func(i *Inventory) any { return i.Storage.CSINodes }},
+	"storage/volumeattachments":                   {"csi", func(i *Inventory) any { return i.Storage.VolumeAttachments }},
+	"storage/volumesnapshotclasses":               {"volume_snapshot", func(i *Inventory) any { return i.Storage.VolumeSnapshotClasses }},
+	"storage/volumesnapshots":                     {"volume_snapshot", func(i *Inventory) any { return i.Storage.VolumeSnapshots }},
+	"networking/services":                         {"service", func(i *Inventory) any { return i.Networking.Services }},
+	"networking/ingresses":                        {"ingress", func(i *Inventory) any { return i.Networking.Ingresses }},
+	"networking/ingressclasses":                   {"ingress", func(i *Inventory) any { return i.Networking.IngressClasses }},
+	"networking/gatewayclasses":                   {"gateway", func(i *Inventory) any { return i.Networking.GatewayClasses }},
+	"networking/gateways":                         {"gateway", func(i *Inventory) any { return i.Networking.Gateways }},
+	"networking/httproutes":                       {"gateway", func(i *Inventory) any { return i.Networking.HTTPRoutes }},
+	"networking/grpcroutes":                       {"gateway", func(i *Inventory) any { return i.Networking.GRPCRoutes }},
+	"networking/tlsroutes":                        {"gateway", func(i *Inventory) any { return i.Networking.TLSRoutes }},
+	"networking/httpproxies":                      {"contour", func(i *Inventory) any { return i.Networking.HTTPProxies }},
+	"availability/horizontalpodautoscalers":       {"autoscaling", func(i *Inventory) any { return i.Availability.HorizontalPodAutoscalers }},
+	"availability/verticalpodautoscalers":         {"vertical_pod_autoscaler", func(i *Inventory) any { return i.Availability.VerticalPodAutoscalers }},
+	"availability/poddisruptionbudgets":           {"pod_disruption_budget", func(i *Inventory) any { return i.Availability.PodDisruptionBudgets }},
+	"rbac/serviceaccounts":                        {"rbac", func(i *Inventory) any { return i.RBAC.ServiceAccounts }},
+	"rbac/roles":                                  {"rbac", func(i *Inventory) any { return i.RBAC.Roles }},
+	"rbac/clusterroles":                           {"rbac", func(i *Inventory) any { return i.RBAC.ClusterRoles }},
+	"rbac/rolebindings":                           {"rbac", func(i *Inventory) any { return i.RBAC.RoleBindings }},
+	"rbac/clusterrolebindings":                    {"rbac", func(i *Inventory) any { return i.RBAC.ClusterRoleBindings }},
+	"admission/mutatingwebhookconfigurations":     {"admission", func(i *Inventory) any { return i.Admission.MutatingWebhookConfigurations }},
+	"admission/validatingwebhookconfigurations":   {"admission", func(i *Inventory) any { return i.Admission.ValidatingWebhookConfigurations }},
+	"admission/validatingadmissionpolicies":       {"admission", func(i *Inventory) any { return i.Admission.ValidatingAdmissionPolicies }},
+	"admission/validatingadmissionpolicybindings": {"admission", func(i *Inventory) any { return i.Admission.ValidatingAdmissionPolicyBindings }},
+	"policy/kyvernoclusterpolicies":               {"kyverno", func(i *Inventory) any { return i.CustomResources.Kyverno.ClusterPolicies }},
+	"policy/kyvernopolicies":                      {"kyverno", func(i *Inventory) any { return i.CustomResources.Kyverno.Policies }},
+	"policy/constrainttemplates":                  {"gatekeeper", func(i *Inventory) any { return i.CustomResources.Gatekeeper.ConstraintTemplates }},
+	"policy/constraints":                          {"gatekeeper", func(i *Inventory) any { return i.CustomResources.Gatekeeper.Constraints }},
+	"certificates/issuers":                        {"cert_manager", func(i *Inventory) any { return i.CustomResources.CertManager.Issuers }},
+	"certificates/clusterissuers":                 {"cert_manager", func(i *Inventory) any { return i.CustomResources.CertManager.ClusterIssuers }},
+	"certificates/certificates":                   {"cert_manager", func(i *Inventory) any { return i.CustomResources.CertManager.Certificates }},
+	"certificates/failedcertificaterequests":      {"cert_manager", func(i *Inventory) any { return i.CustomResources.CertManager.FailedCertificateRequests }},
+	"gitops/gitrepositories":                      {"flux", func(i *Inventory) any { return i.CustomResources.Flux.GitRepositories }},
+	"gitops/ocirepositories":                      {"flux", func(i *Inventory) any { return i.CustomResources.Flux.OCIRepositories }},
+	"gitops/helmrepositories":                     {"flux", func(i *Inventory) any { return i.CustomResources.Flux.HelmRepositories }},
+	"gitops/kustomizations":                       {"flux", func(i *Inventory) any { return i.CustomResources.Flux.Kustomizations }},
+	"gitops/helmreleases":                         {"flux", func(i *Inventory) any { return i.CustomResources.Flux.HelmReleases }},
+}
+
+// apiObject is an object of the query API in its JSON form
+type apiObject struct {
+	key       string
+	kind      string
+	namespace string
+	labels    labels.Set
+	object    map[string]any
+}
+
+type apiList struct {
+	Metadata apiListMeta `json:"metadata"`
+	Items    []any       `json:"items"`
+}
+
+type apiListMeta struct {
+	Generation         uint64 `json:"generation"`
+	Continue           string `json:"continue,omitempty"`
+	RemainingItemCount *int   `json:"remainingItemCount,omitempty"`
+}
+
+// sectionCache holds the objects of a snapshot in their JSON form, sorted by
+// kind, namespace and name. Sections are converted on first use.
+type sectionCache struct {
+	mu       sync.Mutex
+	sections map[string][]apiObject
+}
+
+func (s *snapshot) section(name string) ([]apiObject, error) {
+	s.api.mu.Lock()
+	defer s.api.mu.Unlock()
+	if objs, found := s.api.sections[name]; found {
+		return objs, nil
+	}
+	objs, err := toAPIObjects(apiSections[name].get(s.Inventory))
+	if err != nil {
+		return nil, err
+	}
+	if s.api.sections == nil {
+		s.api.sections = make(map[string][]apiObject)
+	}
+	s.api.sections[name] = objs
+	return objs, nil
+}
+
+func toAPIObjects(l any) ([]apiObject, error) {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	var raw []map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	objs := make([]apiObject, 0, len(raw))
+	for _, o := range raw {
+		if o == nil {
+			continue
+		}
+		meta, _ := o["metadata"].(map[string]any)
+		name, _ := meta["name"].(string)
+		namespace, _ := meta["namespace"].(string)
+		kind, _ := o["kind"].(string)
+		ls := labels.Set{}
+		if m, ok := meta["labels"].(map[string]any); ok {
+			for k, v := range m {
+				ls[k], _ = v.(string)
+			}
+		}
+		objs = append(objs, apiObject{
+			key:       kind + "/" + namespace + "/" + name,
+			kind:      kind,
+			namespace: namespace,
+			labels:    ls,
+			object:    o,
+		})
+	}
+	sort.Slice(objs, func(i, j int) bool { return objs[i].key < objs[j].key })
+	return objs, nil
+}
+
+// APIHandler returns the handler of the query API. It serves from the current
+// snapshot and is mounted at /api/.
+func (c *InventoryCollection) APIHandler() http.Handler {
+	mux := http.NewServeMux()
+	for name := range apiSections {
+		mux.HandleFunc("GET /api/v1/"+name, c.listHandler(name))
+	}
+	mux.HandleFunc("GET /api/v1/history", c.serveHistory)
+	mux.HandleFunc("GET /api/v1/diff", c.serveDiff)
+	mux.HandleFunc("GET /api/v1/watch", c.serveWatch)
+	return mux
+}
+
+// listHandler serves a section of the inventory. Objects can be filtered by
+// namespace, kind and label selector, reduced to the fields given as dotted
+// paths and paged through with limit and continue like the Kubernetes API.
+func (c *InventoryCollection) listHandler(section string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+		s := c.snapshot()
+		if s == nil {
+			http.Error(w, errHTTPNoInventory.JSON(), http.StatusServiceUnavailable)
+			return
+		}
+
+		q := r.URL.Query()
+		selector, err := labels.Parse(q.Get("labelSelector"))
+		if err != nil {
+			apiError(w, http.StatusBadRequest, fmt.Sprintf("parsing labelSelector: %v", err))
+			return
+		}
+		limit := defaultAPILimit
+		if l := q.Get("limit"); l != "" {
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+				apiError(w, http.StatusBadRequest, "limit must be a positive integer")
+				return
+			}
+			limit = min(limit, maxAPILimit)
+		}
+		after := ""
+		if cont := q.Get("continue"); cont != "" {
+			b, err := base64.RawURLEncoding.DecodeString(cont)
+			if err != nil {
+				apiError(w, http.StatusBadRequest, "invalid continue token")
+				return
+			}
+			after = string(b)
+		}
+		var fields [][]string
+		if f := q.Get("fields"); f != "" {
+			for _, p := range strings.Split(f, ",") {
+				fields = append(fields, strings.Split(strings.TrimSpace(p), "."))
+			}
+		}
+
+		objs, err := s.section(section)
+		if err != nil {
+			log.Error().Err(err).Str("section", section).Msg("converting inventory")
+			http.Error(w, errHTTPInternalError.JSON(), http.StatusInternalServerError)
+			return
+		}
+		start := sort.Search(len(objs), func(i int) bool { return objs[i].key > after })
+
+		list := apiList{Metadata: apiListMeta{Generation: s.Generation}, Items: []any{}}
+		namespace, kind := q.Get("namespace"), q.Get("kind")
+		for n, o := range objs[start:] {
+			if (namespace != "" && o.namespace != namespace) ||
+				(kind != "" && !strings.EqualFold(o.kind, kind)) ||
+				!selector.Matches(o.labels) {
+				continue
+			}
+			if len(list.Items) == limit {
+				remaining := countMatching(objs[start+n:], namespace, kind, selector)
+				list.Metadata.Continue = base64.RawURLEncoding.EncodeToString([]byte(objs[start+n-1].key))
+				list.Metadata.RemainingItemCount = &remaining
+				break
+			}
+			list.Items = append(list.Items, project(o.object, fields))
+		}
+
+		w.Header().Set("X-Inventory-Generation", strconv.FormatUint(s.Generation, 10))
+		writeJSON(w, list)
+	}
+}
+
+func countMatching(objs []apiObject, namespace, kind string, selector labels.Selector) int {
+	n := 0
+	for _, o := range objs {
+		if (namespace == "" || o.namespace == namespace) &&
+			(kind == "" || strings.EqualFold(o.kind, kind)) &&
+			selector.Matches(o.labels) {
+			n++
+		}
+	}
+	return n
+}
+
+// project returns the fields of o given as paths. All of o is returned if no
+// fields are given.
+func project(o map[string]any, fields [][]string) map[string]any {
+	if len(fields) == 0 {
+		return o
+	}
+	ret := make(map[string]any)
+	for _, path := range fields {
+		var (
+			v     any = o
+			found     = true
+		)
+		for _, p := range path {
+			m, ok := v.(map[string]any)
+			if !ok {
+				found = false
+				break
+			}
+			if v, found = m[p]; !found {
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		dst := ret
+		for _, p := range path[:len(path)-1] {
+			next, ok := dst[p].(map[string]any)
+			if !ok {
+				next = make(map[string]any)
+				dst[p] = next
+			}
+			dst = next
+		}
+		dst[path[len(path)-1]] = v
+	}
+	return ret
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug().Err(err).Msg("writing response")
+	}
+}
+
+func apiError(w http.ResponseWriter, code int, msg string) {
+	http.Error(w, (&errHTTP{StatusCode: code, Message: msg}).JSON(), code)
+}
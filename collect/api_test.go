@@ -0,0 +1,138 @@
+package collect
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// testAPICollection returns a collection serving pods a-0 to a-4 in namespace
+// a and b-0 to b-4 in namespace b, with every other pod labeled even
+func testAPICollection(t *testing.T) *InventoryCollection {
+	t.Helper()
+	var pods []map[string]any
+	for _, ns := range []string{"a", "b"} {
+		for n := 0; n < 5; n++ {
+			meta := map[string]any{"name": fmt.Sprintf("%s-%d", ns, n), "namespace": ns}
+			if n%2 == 0 {
+				meta["labels"] = map[string]any{"even": "true"}
+			}
+			pods = append(pods, map[string]any{"kind": "Pod", "metadata": meta})
+		}
+	}
+	objs, err := toAPIObjects(pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &snapshot{Generation: 7}
+	s.api.sections = map[string][]apiObject{"workloads": objs}
+	c := &InventoryCollection{}
+	c.current.Store(s)
+	return c
+}
+
+func TestListPagination(t *testing.T) {
+	c := testAPICollection(t)
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []string
+	}{
+		{
+			name:  "all",
+			query: url.Values{"limit": {"3"}},
+			want:  []string{"a-0", "a-1", "a-2", "a-3", "a-4", "b-0", "b-1", "b-2", "b-3", "b-4"},
+		},
+		{
+			name:  "namespace",
+			query: url.Values{"limit": {"2"}, "namespace": {"b"}},
+			want:  []string{"b-0", "b-1", "b-2", "b-3", "b-4"},
+		},
+		{
+			name:  "label selector",
+			query: url.Values{"limit": {"2"}, "labelSelector": {"even=true"}},
+			want:  []string{"a-0", "a-2", "a-4", "b-0", "b-2", "b-4"},
+		},
+		{
+			name:  "page size of all matching",
+			query: url.Values{"limit": {"5"}, "namespace": {"a"}},
+			want:  []string{"a-0", "a-1", "a-2", "a-3", "a-4"},
+		},
+		{
+			name:  "no match",
+			query: url.Values{"limit": {"2"}, "kind": {"Deployment"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			q := tt.query
+			for pages := 0; ; pages++ {
+				if pages > len(tt.want) {
+					t.Fatal("pagination does not end")
+				}
+				list := listPage(t, c, q, http.StatusOK)
+				if list.Metadata.Generation != 7 {
+					t.Errorf("generation: got %d", list.Metadata.Generation)
+				}
+				for _, o := range list.Items {
+					got = append(got, o.Metadata.Name)
+				}
+				if list.Metadata.Continue == "" {
+					if list.Metadata.RemainingItemCount != nil {
+						t.Errorf("remaining items on the last page: %d", *list.Metadata.RemainingItemCount)
+					}
+					break
+				}
+				if r := list.Metadata.RemainingItemCount; r == nil || *r != len(tt.want)-len(got) {
+					t.Errorf("remaining items after %v: got %v", got, r)
+				}
+				q.Set("continue", list.Metadata.Continue)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListInvalid(t *testing.T) {
+	c := testAPICollection(t)
+	for _, q := range []url.Values{
+		{"continue": {"not base64!"}},
+		{"limit": {"0"}},
+		{"limit": {"x"}},
+		{"labelSelector": {"a in"}},
+	} {
+		listPage(t, c, q, http.StatusBadRequest)
+	}
+}
+
+type testList struct {
+	Metadata apiListMeta `json:"metadata"`
+	Items    []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	} `json:"items"`
+}
+
+func listPage(t *testing.T, c *InventoryCollection, q url.Values, code int) testList {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.listHandler("workloads")(rec, httptest.NewRequest(http.MethodGet, "/api/v1/workloads?"+q.Encode(), nil))
+	if rec.Code != code {
+		t.Fatalf("%s: got status %d, want %d: %s", q.Encode(), rec.Code, code, rec.Body)
+	}
+	var list testList
+	if code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return list
+}
@@ -0,0 +1,333 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	Register(WithLink(NewCollector("autoscaling", []string{"autoscaling/v2/horizontalpodautoscalers"}, collectHPAs), linkHPAs))
+	Register(WithLink(NewCollector("vertical_pod_autoscaler", []string{"autoscaling.k8s.io/v1/verticalpodautoscalers"}, collectVPAs), linkVPAs))
+}
+
+type HorizontalPodAutoscaler struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 HorizontalPodAutoscalerSpec   `json:"spec"`
+	Status               HorizontalPodAutoscalerStatus `json:"status"`
+	// Workload is the scale target if it is in the inventory
+	Workload *inventory.RootOwner `json:",omitempty"`
+}
+
+type HorizontalPodAutoscalerSpec struct {
+	ScaleTargetRef Reference
+	MinReplicas    int32
+	MaxReplicas    int32
+	Metrics        []AutoscalerMetric
+}
+
+// AutoscalerMetric is a metric an autoscaler scales on. Target and Current
+// are utilizations in percent, e.g. 80%, or quantities.
+type AutoscalerMetric struct {
+	// Type is Resource, ContainerResource, Pods, Object or External
+	Type string
+	// Name of the resource or metric
+	Name      string
+	Container string     `json:",omitempty"`
+	Object    *Reference `json:",omitempty"`
+	Target    string
+	Current   string `json:",omitempty"`
+}
+
+type HorizontalPodAutoscalerStatus struct {
+	CurrentReplicas int32
+	DesiredReplicas int32
+	LastScaleTime   *metav1.Time `json:",omitempty"`
+	Conditions      []Condition
+}
+
+type VerticalPodAutoscaler struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 VerticalPodAutoscalerSpec   `json:"spec"`
+	Status               VerticalPodAutoscalerStatus `json:"status"`
+	// Workload is the target if it is in the inventory
+	Workload *inventory.RootOwner `json:",omitempty"`
+}
+
+type VerticalPodAutoscalerSpec struct {
+	TargetRef Reference
+	// UpdateMode is Off, Initial, Recreate or Auto
+	UpdateMode string
+}
+
+type VerticalPodAutoscalerStatus struct {
+	Recommendations []ContainerRecommendation
+	Conditions      []Condition
+}
+
+// ContainerRecommendation holds the resources recommended for a container
+type ContainerRecommendation struct {
+	Container  string
+	Target     map[string]string
+	LowerBound map[string]string `json:",omitempty"`
+	UpperBound map[string]string `json:",omitempty"`
+}
+
+// verticalPodAutoscaler holds the fields read from autoscaling.k8s.io/v1
+// VerticalPodAutoscalers
+type verticalPodAutoscaler struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		TargetRef *struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+		} `json:"targetRef"`
+		UpdatePolicy *struct {
+			UpdateMode *string `json:"updateMode"`
+		} `json:"updatePolicy"`
+	} `json:"spec"`
+	Status struct {
+		Recommendation *struct {
+			ContainerRecommendations []struct {
+				ContainerName string                       `json:"containerName"`
+				Target        map[string]resource.Quantity `json:"target"`
+				LowerBound    map[string]resource.Quantity `json:"lowerBound"`
+				UpperBound    map[string]resource.Quantity `json:"upperBound"`
+			} `json:"containerRecommendations"`
+		} `json:"recommendation"`
+		Conditions []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+func NewHorizontalPodAutoscaler() *HorizontalPodAutoscaler {
+	return &HorizontalPodAutoscaler{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "HorizontalPodAutoscaler",
+			APIGroup:     autoscalingv2.GroupName,
+			APIVersion:   "v2",
+			ResourceType: "horizontalpodautoscalers",
+		},
+	}
+}
+
+func NewVerticalPodAutoscaler() *VerticalPodAutoscaler {
+	return &VerticalPodAutoscaler{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "VerticalPodAutoscaler",
+			APIGroup:     "autoscaling.k8s.io",
+			APIVersion:   "v1",
+			ResourceType: "verticalpodautoscalers",
+		},
+	}
+}
+
+func collectHPAs(ctx context.Context, cl *Clients, i *Inventory) error {
+	hpas := make([]*HorizontalPodAutoscaler, 0)
+	hpaList := &autoscalingv2.HorizontalPodAutoscalerList{}
+	if err := cl.Client.List(ctx, hpaList); err != nil {
+		return fmt.Errorf("getting HorizontalPodAutoscalers: %v", err)
+	}
+	for _, o := range hpaList.Items {
+		hpas = append(hpas, collectHPA(o))
+	}
+	i.Availability.HorizontalPodAutoscalers = hpas
+	return nil
+}
+
+func collectHPA(o autoscalingv2.HorizontalPodAutoscaler) *HorizontalPodAutoscaler {
+	r := NewHorizontalPodAutoscaler()
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+
+	ref := o.Spec.ScaleTargetRef
+	r.Spec.ScaleTargetRef = targetRef(ref.APIVersion, ref.Kind, o.Namespace, ref.Name)
+	r.Spec.MinReplicas = 1
+	if o.Spec.MinReplicas != nil {
+		r.Spec.MinReplicas = *o.Spec.MinReplicas
+	}
+	r.Spec.MaxReplicas = o.Spec.MaxReplicas
+
+	current := make(map[AutoscalerMetric]string)
+	for _, m := range o.Status.CurrentMetrics {
+		var (
+			metric AutoscalerMetric
+			value  autoscalingv2.MetricValueStatus
+		)
+		switch {
+		case m.Resource != nil:
+			metric = AutoscalerMetric{Name: string(m.Resource.Name)}
+			value = m.Resource.Current
+		case m.ContainerResource != nil:
+			metric = AutoscalerMetric{Name: string(m.ContainerResource.Name), Container: m.ContainerResource.Container}
+			value = m.ContainerResource.Current
+		case m.Pods != nil:
+			metric = AutoscalerMetric{Name: m.Pods.Metric.Name}
+			value = m.Pods.Current
+		case m.Object != nil:
+			metric = AutoscalerMetric{Name: m.Object.Metric.Name}
+			value = m.Object.Current
+		case m.External != nil:
+			metric = AutoscalerMetric{Name: m.External.Metric.Name}
+			value = m.External.Current
+		}
+		metric.Type = string(m.Type)
+		current[metric] = metricValue(value.AverageUtilization, value.AverageValue, value.Value)
+	}
+
+	r.Spec.Metrics = make([]AutoscalerMetric, 0, len(o.Spec.Metrics))
+	for _, m := range o.Spec.Metrics {
+		var (
+			metric AutoscalerMetric
+			target autoscalingv2.MetricTarget
+		)
+		switch {
+		case m.Resource != nil:
+			metric = AutoscalerMetric{Name: string(m.Resource.Name)}
+			target = m.Resource.Target
+		case m.ContainerResource != nil:
+			metric = AutoscalerMetric{Name: string(m.ContainerResource.Name), Container: m.ContainerResource.Container}
+			target = m.ContainerResource.Target
+		case m.Pods != nil:
+			metric = AutoscalerMetric{Name: m.Pods.Metric.Name}
+			target = m.Pods.Target
+		case m.Object != nil:
+			metric = AutoscalerMetric{Name: m.Object.Metric.Name}
+			target = m.Object.Target
+		case m.External != nil:
+			metric = AutoscalerMetric{Name: m.External.Metric.Name}
+			target = m.External.Target
+		}
+		metric.Type = string(m.Type)
+		metric.Current = current[metric]
+		metric.Target = metricValue(target.AverageUtilization, target.AverageValue, target.Value)
+		if m.Object != nil {
+			d := m.Object.DescribedObject
+			ref := targetRef(d.APIVersion, d.Kind, o.Namespace, d.Name)
+			metric.Object = &ref
+		}
+		r.Spec.Metrics = append(r.Spec.Metrics, metric)
+	}
+
+	r.Status.CurrentReplicas = o.Status.CurrentReplicas
+	r.Status.DesiredReplicas = o.Status.DesiredReplicas
+	r.Status.LastScaleTime = o.Status.LastScaleTime
+	r.Status.Conditions = make([]Condition, 0, len(o.Status.Conditions))
+	for _, c := range o.Status.Conditions {
+		r.Status.Conditions = append(r.Status.Conditions, Condition{
+			Type:               string(c.Type),
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime,
+		})
+	}
+	return r
+}
+
+// metricValue formats the value set of a metric target or status
+func metricValue(utilization *int32, average, value *resource.Quantity) string {
+	switch {
+	case utilization != nil:
+		return fmt.Sprintf("%d%%", *utilization)
+	case average != nil:
+		return average.String()
+	case value != nil:
+		return value.String()
+	}
+	return ""
+}
+
+func collectVPAs(ctx context.Context, cl *Clients, i *Inventory) error {
+	vpas := make([]*VerticalPodAutoscaler, 0)
+	objs, _, err := listCustomObjects[verticalPodAutoscaler](ctx, cl.Clientset, "/apis/autoscaling.k8s.io/v1/verticalpodautoscalers")
+	for _, o := range objs {
+		r := NewVerticalPodAutoscaler()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		if ref := o.Spec.TargetRef; ref != nil {
+			r.Spec.TargetRef = targetRef(ref.APIVersion, ref.Kind, o.Namespace, ref.Name)
+		}
+		r.Spec.UpdateMode = "Auto"
+		if p := o.Spec.UpdatePolicy; p != nil && p.UpdateMode != nil {
+			r.Spec.UpdateMode = *p.UpdateMode
+		}
+		r.Status.Recommendations = make([]ContainerRecommendation, 0)
+		if rec := o.Status.Recommendation; rec != nil {
+			for _, c := range rec.ContainerRecommendations {
+				r.Status.Recommendations = append(r.Status.Recommendations, ContainerRecommendation{
+					Container:  c.ContainerName,
+					Target:     quantities(c.Target),
+					LowerBound: quantities(c.LowerBound),
+					UpperBound: quantities(c.UpperBound),
+				})
+			}
+		}
+		r.Status.Conditions = conditions(o.Status.Conditions)
+		vpas = append(vpas, r)
+	}
+	i.Availability.VerticalPodAutoscalers = vpas
+	return err
+}
+
+func quantities(l map[string]resource.Quantity) map[string]string {
+	if l == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(l))
+	for k, q := range l {
+		ret[k] = q.String()
+	}
+	return ret
+}
+
+// targetRef refers to the object of kind and name given with apiVersion in
+// namespace
+func targetRef(apiVersion, kind, namespace, name string) Reference {
+	gv, _ := schema.ParseGroupVersion(apiVersion)
+	return Reference{APIGroup: gv.Group, Kind: kind, Namespace: namespace, Name: name}
+}
+
+// linkHPAs links autoscalers and the workloads they scale to each other
+func linkHPAs(i *Inventory) {
+	workloads := workloadsByRef(i)
+	for _, a := range i.Availability.HorizontalPodAutoscalers {
+		a.Workload = workloads[a.Spec.ScaleTargetRef]
+		if a.Workload != nil {
+			l := i.workloadLinks(*a.Workload)
+			l.HorizontalPodAutoscalers = append(l.HorizontalPodAutoscalers, Reference{APIGroup: a.APIGroup, Kind: a.Kind, Namespace: a.Namespace, Name: a.Name})
+		}
+	}
+}
+
+// linkVPAs links autoscalers and the workloads whose pods they size to each
+// other
+func linkVPAs(i *Inventory) {
+	workloads := workloadsByRef(i)
+	for _, a := range i.Availability.VerticalPodAutoscalers {
+		a.Workload = workloads[a.Spec.TargetRef]
+		if a.Workload != nil {
+			l := i.workloadLinks(*a.Workload)
+			l.VerticalPodAutoscalers = append(l.VerticalPodAutoscalers, Reference{APIGroup: a.APIGroup, Kind: a.Kind, Namespace: a.Namespace, Name: a.Name})
+		}
+	}
+}
+
+// workloadsByRef returns the workloads of the inventory keyed by references
+// to them
+func workloadsByRef(i *Inventory) map[Reference]*inventory.RootOwner {
+	ret := make(map[Reference]*inventory.RootOwner)
+	for _, w := range i.Workloads {
+		if w == nil {
+			continue
+		}
+		o := asRootOwner(w)
+		ret[Reference{APIGroup: o.APIGroup, Kind: o.Kind, Namespace: o.Namespace, Name: o.Name}] = &o
+	}
+	return ret
+}
@@ -0,0 +1,36 @@
+package collect
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestMetricValue(t *testing.T) {
+	utilization := int32(80)
+	zero := int32(0)
+	average := resource.MustParse("500m")
+	value := resource.MustParse("2Gi")
+	tests := []struct {
+		name        string
+		utilization *int32
+		average     *resource.Quantity
+		value       *resource.Quantity
+		want        string
+	}{
+		{name: "none"},
+		{name: "utilization", utilization: &utilization, want: "80%"},
+		{name: "zero utilization", utilization: &zero, want: "0%"},
+		{name: "average", average: &average, want: "500m"},
+		{name: "value", value: &value, want: "2Gi"},
+		{name: "utilization before average", utilization: &utilization, average: &average, want: "80%"},
+		{name: "average before value", average: &average, value: &value, want: "500m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metricValue(tt.utilization, tt.average, tt.value); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// watchedObjects are the kinds read by the collectors that are kept in the
+// informer cache when caching is enabled. A change to any of them triggers a
+// rebuild of the inventory. Kinds that are not served by all supported
+// versions of Kubernetes, like ValidatingAdmissionPolicies, are left out.
+var watchedObjects = []client.Object{
+	&v1.Namespace{},
+	&v1.ResourceQuota{},
+	&v1.LimitRange{},
+	&schedulingv1.PriorityClass{},
+	&v1.Node{},
+	&v1.PersistentVolume{},
+	&v1.PersistentVolumeClaim{},
+	&v1.Pod{},
+	&storagev1.StorageClass{},
+	&storagev1.CSIDriver{},
+	&storagev1.CSINode{},
+	&storagev1.VolumeAttachment{},
+	&networkingv1.NetworkPolicy{},
+	&networkingv1.Ingress{},
+	&networkingv1.IngressClass{},
+	&v1.Service{},
+	&discoveryv1.EndpointSlice{},
+	&v1.ServiceAccount{},
+	&rbacv1.Role{},
+	&rbacv1.ClusterRole{},
+	&rbacv1.RoleBinding{},
+	&rbacv1.ClusterRoleBinding{},
+	&autoscalingv2.HorizontalPodAutoscaler{},
+	&policyv1.PodDisruptionBudget{},
+	&admissionv1.MutatingWebhookConfiguration{},
+	&admissionv1.ValidatingWebhookConfiguration{},
+	&appsv1.Deployment{},
+	&appsv1.StatefulSet{},
+	&appsv1.ReplicaSet{},
+	&appsv1.DaemonSet{},
+	&batchv1.CronJob{},
+	&batchv1.Job{},
+}
+
+// watchCache starts informers for watchedObjects and signals changed whenever
+// one of them receives an event. Signals are coalesced, so a pending signal
+// represents any number of events. Each informer must sync within
+// syncTimeout, which fails for kinds that can't be listed.
+func watchCache(ctx context.Context, ca cache.Cache, changed chan<- struct{}, syncTimeout time.Duration) error {
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+	handler := toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { notify() },
+		UpdateFunc: func(interface{}, interface{}) { notify() },
+		DeleteFunc: func(interface{}) { notify() },
+	}
+	for _, o := range watchedObjects {
+		informer, err := getInformer(ctx, ca, o, syncTimeout)
+		if err != nil {
+			return fmt.Errorf("getting informer for %T: %v", o, err)
+		}
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return fmt.Errorf("adding event handler for %T: %v", o, err)
+		}
+	}
+	return nil
+}
+
+// getInformer returns the informer for o once it has synced
+func getInformer(ctx context.Context, ca cache.Cache, o client.Object, syncTimeout time.Duration) (cache.Informer, error) {
+	ctx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	informer, err := ca.GetInformer(ctx, o)
+	if err != nil && ctx.Err() != nil {
+		return nil, fmt.Errorf("not synced within %v", syncTimeout)
+	}
+	return informer, err
+}
@@ -1,16 +1,28 @@
 package collect
 
 import (
+	"context"
+
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	"github.com/neticdk-k8s/k8s-inventory-client/kubernetes"
 	calicoapi "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectCalico(cs *ck.Clientset) (*inventory.CalicoClusterInformation, error) {
+func init() {
+	Register(NewCollector("calico", []string{"crd.projectcalico.org/v1/clusterinformations"}, collectCalico))
+}
+
+func collectCalico(ctx context.Context, cl *Clients, i *Inventory) error {
+	calico, err := collectCalicoClusterInformation(ctx, cl.Clientset)
+	i.CustomResources.CalicoCluster = calico
+	return err
+}
+
+func collectCalicoClusterInformation(ctx context.Context, cs *ck.Clientset) (*inventory.CalicoClusterInformation, error) {
 	r := inventory.NewCalicoClusterInformation()
 
-	res, found, err := kubernetes.GetK8SRESTResource(cs, "/apis/crd.projectcalico.org/v1/clusterinformations/default")
+	res, found, err := kubernetes.GetK8SRESTResource(ctx, cs, "/apis/crd.projectcalico.org/v1/clusterinformations/default")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,327 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ck "k8s.io/client-go/kubernetes"
+)
+
+func init() {
+	Register(NewCollector("cert_manager", []string{"cert-manager.io/v1/issuers"}, collectCertManager))
+}
+
+const certManagerGroup = "cert-manager.io"
+
+type CertManager struct {
+	Issuers        []*Issuer
+	ClusterIssuers []*Issuer
+	Certificates   []*Certificate
+	// FailedCertificateRequests are the requests which have failed or been
+	// denied
+	FailedCertificateRequests []*CertificateRequest
+	// Expiring lists the certificates expiring within
+	// CERTIFICATE_EXPIRY_DAYS, or which have expired
+	Expiring []Reference
+	// NotReady lists the certificates whose Ready condition is not true
+	NotReady []Reference
+}
+
+// Issuer is a cert-manager Issuer or ClusterIssuer
+type Issuer struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 IssuerSpec   `json:"spec"`
+	Status               IssuerStatus `json:"status"`
+}
+
+type IssuerSpec struct {
+	// Type is ACME, CA, Vault, SelfSigned or Venafi
+	Type string
+	// Server is the ACME directory or Vault server
+	Server string `json:",omitempty"`
+}
+
+type IssuerStatus struct {
+	Ready      bool
+	Conditions []Condition
+}
+
+type Certificate struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 CertificateSpec   `json:"spec"`
+	Status               CertificateStatus `json:"status"`
+}
+
+type CertificateSpec struct {
+	SecretName  string
+	CommonName  string `json:",omitempty"`
+	DNSNames    []string
+	IssuerRef   Reference
+	Duration    string `json:",omitempty"`
+	RenewBefore string `json:",omitempty"`
+}
+
+type CertificateStatus struct {
+	Ready       bool
+	NotBefore   *metav1.Time `json:",omitempty"`
+	NotAfter    *metav1.Time `json:",omitempty"`
+	RenewalTime *metav1.Time `json:",omitempty"`
+	Revision    int
+	Conditions  []Condition
+}
+
+type CertificateRequest struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 CertificateRequestSpec   `json:"spec"`
+	Status               CertificateRequestStatus `json:"status"`
+}
+
+type CertificateRequestSpec struct {
+	IssuerRef Reference
+	// Certificate is the name of the certificate the request was made for
+	Certificate string `json:",omitempty"`
+}
+
+type CertificateRequestStatus struct {
+	// Reason is Failed or Denied
+	Reason      string
+	Message     string
+	FailureTime *metav1.Time `json:",omitempty"`
+	Conditions  []Condition
+}
+
+// issuerRef holds the issuerRef of certificates and certificate requests
+type issuerRef struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+// issuer holds the fields read from cert-manager.io/v1 Issuers and
+// ClusterIssuers
+type issuer struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		ACME *struct {
+			Server string `json:"server"`
+		} `json:"acme"`
+		CA    *struct{} `json:"ca"`
+		Vault *struct {
+			Server string `json:"server"`
+		} `json:"vault"`
+		SelfSigned *struct{} `json:"selfSigned"`
+		Venafi     *struct{} `json:"venafi"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+// certificate holds the fields read from cert-manager.io/v1 Certificates
+type certificate struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		SecretName  string    `json:"secretName"`
+		CommonName  string    `json:"commonName"`
+		DNSNames    []string  `json:"dnsNames"`
+		IssuerRef   issuerRef `json:"issuerRef"`
+		Duration    string    `json:"duration"`
+		RenewBefore string    `json:"renewBefore"`
+	} `json:"spec"`
+	Status struct {
+		NotBefore   *metav1.Time       `json:"notBefore"`
+		NotAfter    *metav1.Time       `json:"notAfter"`
+		RenewalTime *metav1.Time       `json:"renewalTime"`
+		Revision    int                `json:"revision"`
+		Conditions  []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+// certificateRequest holds the fields read from cert-manager.io/v1
+// CertificateRequests
+type certificateRequest struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		IssuerRef issuerRef `json:"issuerRef"`
+	} `json:"spec"`
+	Status struct {
+		FailureTime *metav1.Time       `json:"failureTime"`
+		Conditions  []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+func NewIssuer(kind, resourceType string) *Issuer {
+	return &Issuer{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     certManagerGroup,
+			APIVersion:   "v1",
+			ResourceType: resourceType,
+		},
+	}
+}
+
+func NewCertificate() *Certificate {
+	return &Certificate{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "Certificate",
+			APIGroup:     certManagerGroup,
+			APIVersion:   "v1",
+			ResourceType: "certificates",
+		},
+	}
+}
+
+func NewCertificateRequest() *CertificateRequest {
+	return &CertificateRequest{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "CertificateRequest",
+			APIGroup:     certManagerGroup,
+			APIVersion:   "v1",
+			ResourceType: "certificaterequests",
+		},
+	}
+}
+
+func collectCertManager(ctx context.Context, cl *Clients, i *Inventory) error {
+	issuers, issuersErr := collectIssuers(ctx, cl.Clientset, "Issuer", "issuers")
+	i.CustomResources.CertManager.Issuers = issuers
+	clusterIssuers, clusterIssuersErr := collectIssuers(ctx, cl.Clientset, "ClusterIssuer", "clusterissuers")
+	i.CustomResources.CertManager.ClusterIssuers = clusterIssuers
+	certificates, certificatesErr := collectCertificates(ctx, cl.Clientset)
+	i.CustomResources.CertManager.Certificates = certificates
+	requests, requestsErr := collectFailedCertificateRequests(ctx, cl.Clientset)
+	i.CustomResources.CertManager.FailedCertificateRequests = requests
+
+	expiry := time.Now().AddDate(0, 0, cl.CertificateExpiryDays)
+	i.CustomResources.CertManager.Expiring = make([]Reference, 0)
+	i.CustomResources.CertManager.NotReady = make([]Reference, 0)
+	for _, c := range certificates {
+		ref := Reference{APIGroup: certManagerGroup, Kind: c.Kind, Namespace: c.Namespace, Name: c.Name}
+		if c.Status.NotAfter != nil && c.Status.NotAfter.Time.Before(expiry) {
+			i.CustomResources.CertManager.Expiring = append(i.CustomResources.CertManager.Expiring, ref)
+		}
+		if !c.Status.Ready {
+			i.CustomResources.CertManager.NotReady = append(i.CustomResources.CertManager.NotReady, ref)
+		}
+	}
+	return errors.Join(issuersErr, clusterIssuersErr, certificatesErr, requestsErr)
+}
+
+func collectIssuers(ctx context.Context, cs *ck.Clientset, kind, resourceType string) ([]*Issuer, error) {
+	issuers := make([]*Issuer, 0)
+	objs, _, err := listCustomObjects[issuer](ctx, cs, "/apis/cert-manager.io/v1/"+resourceType)
+	for _, o := range objs {
+		r := NewIssuer(kind, resourceType)
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		switch s := o.Spec; {
+		case s.ACME != nil:
+			r.Spec = IssuerSpec{Type: "ACME", Server: s.ACME.Server}
+		case s.CA != nil:
+			r.Spec.Type = "CA"
+		case s.Vault != nil:
+			r.Spec = IssuerSpec{Type: "Vault", Server: s.Vault.Server}
+		case s.SelfSigned != nil:
+			r.Spec.Type = "SelfSigned"
+		case s.Venafi != nil:
+			r.Spec.Type = "Venafi"
+		}
+		r.Status.Ready = meta.IsStatusConditionTrue(o.Status.Conditions, "Ready")
+		r.Status.Conditions = conditions(o.Status.Conditions)
+		issuers = append(issuers, r)
+	}
+	return issuers, err
+}
+
+func collectCertificates(ctx context.Context, cs *ck.Clientset) ([]*Certificate, error) {
+	certificates := make([]*Certificate, 0)
+	objs, _, err := listCustomObjects[certificate](ctx, cs, "/apis/cert-manager.io/v1/certificates")
+	for _, o := range objs {
+		r := NewCertificate()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec = CertificateSpec{
+			SecretName:  o.Spec.SecretName,
+			CommonName:  o.Spec.CommonName,
+			DNSNames:    o.Spec.DNSNames,
+			IssuerRef:   certificateIssuerRef(o.Spec.IssuerRef, o.Namespace),
+			Duration:    o.Spec.Duration,
+			RenewBefore: o.Spec.RenewBefore,
+		}
+		if r.Spec.DNSNames == nil {
+			r.Spec.DNSNames = make([]string, 0)
+		}
+		r.Status = CertificateStatus{
+			Ready:       meta.IsStatusConditionTrue(o.Status.Conditions, "Ready"),
+			NotBefore:   o.Status.NotBefore,
+			NotAfter:    o.Status.NotAfter,
+			RenewalTime: o.Status.RenewalTime,
+			Revision:    o.Status.Revision,
+			Conditions:  conditions(o.Status.Conditions),
+		}
+		certificates = append(certificates, r)
+	}
+	return certificates, err
+}
+
+// collectFailedCertificateRequests returns the requests which are denied or
+// have failed
+func collectFailedCertificateRequests(ctx context.Context, cs *ck.Clientset) ([]*CertificateRequest, error) {
+	requests := make([]*CertificateRequest, 0)
+	objs, _, err := listCustomObjects[certificateRequest](ctx, cs, "/apis/cert-manager.io/v1/certificaterequests")
+	for _, o := range objs {
+		reason, failed := certificateRequestFailure(o.Status.Conditions)
+		if failed == nil {
+			continue
+		}
+		r := NewCertificateRequest()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec.IssuerRef = certificateIssuerRef(o.Spec.IssuerRef, o.Namespace)
+		r.Spec.Certificate = o.Annotations["cert-manager.io/certificate-name"]
+		r.Status = CertificateRequestStatus{
+			Reason:      reason,
+			Message:     failed.Message,
+			FailureTime: o.Status.FailureTime,
+			Conditions:  conditions(o.Status.Conditions),
+		}
+		requests = append(requests, r)
+	}
+	return requests, err
+}
+
+// certificateRequestFailure returns why a request is denied or failed, and
+// the condition saying so. The condition is nil for requests which are
+// pending or issued. A request is failed if its Ready condition is not true
+// with the reason Failed.
+func certificateRequestFailure(conds []metav1.Condition) (reason string, c *metav1.Condition) {
+	if c = meta.FindStatusCondition(conds, "Denied"); c != nil && c.Status == metav1.ConditionTrue {
+		return "Denied", c
+	}
+	if c = meta.FindStatusCondition(conds, "Ready"); c != nil && c.Status != metav1.ConditionTrue && c.Reason == "Failed" {
+		return "Failed", c
+	}
+	return "", nil
+}
+
+// certificateIssuerRef returns the issuer referenced from namespace with the
+// defaults of cert-manager filled in. Issuers not of kind ClusterIssuer are
+// taken to be in the same namespace.
+func certificateIssuerRef(ref issuerRef, namespace string) Reference {
+	r := Reference{APIGroup: ref.Group, Kind: ref.Kind, Name: ref.Name}
+	if r.APIGroup == "" {
+		r.APIGroup = certManagerGroup
+	}
+	if r.Kind == "" {
+		r.Kind = "Issuer"
+	}
+	if r.Kind != "ClusterIssuer" {
+		r.Namespace = namespace
+	}
+	return r
+}
@@ -0,0 +1,63 @@
+package collect
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCertificateRequestFailure(t *testing.T) {
+	cond := func(typ string, status metav1.ConditionStatus, reason string) metav1.Condition {
+		return metav1.Condition{Type: typ, Status: status, Reason: reason, Message: typ + " " + reason}
+	}
+	tests := []struct {
+		name    string
+		conds   []metav1.Condition
+		reason  string
+		message string
+	}{
+		{name: "no conditions"},
+		{name: "pending", conds: []metav1.Condition{cond("Ready", metav1.ConditionFalse, "Pending")}},
+		{name: "issued", conds: []metav1.Condition{cond("Ready", metav1.ConditionTrue, "Issued")}},
+		{
+			name:    "failed",
+			conds:   []metav1.Condition{cond("Approved", metav1.ConditionTrue, "Approved"), cond("Ready", metav1.ConditionFalse, "Failed")},
+			reason:  "Failed",
+			message: "Ready Failed",
+		},
+		{
+			name:    "denied",
+			conds:   []metav1.Condition{cond("Denied", metav1.ConditionTrue, "Denied"), cond("Ready", metav1.ConditionFalse, "Denied")},
+			reason:  "Denied",
+			message: "Denied Denied",
+		},
+		{
+			name:    "denied before failed",
+			conds:   []metav1.Condition{cond("Ready", metav1.ConditionFalse, "Failed"), cond("Denied", metav1.ConditionTrue, "Denied")},
+			reason:  "Denied",
+			message: "Denied Denied",
+		},
+		{
+			name:    "denial withdrawn",
+			conds:   []metav1.Condition{cond("Denied", metav1.ConditionFalse, "Denied"), cond("Ready", metav1.ConditionFalse, "Failed")},
+			reason:  "Failed",
+			message: "Ready Failed",
+		},
+		{name: "denied false", conds: []metav1.Condition{cond("Denied", metav1.ConditionFalse, "Denied")}},
+		{name: "ready unknown", conds: []metav1.Condition{cond("Ready", metav1.ConditionUnknown, "")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, c := certificateRequestFailure(tt.conds)
+			if reason != tt.reason {
+				t.Errorf("reason: got %q, want %q", reason, tt.reason)
+			}
+			if (c == nil) != (tt.reason == "") {
+				t.Fatalf("condition: got %v", c)
+			}
+			if c != nil && c.Message != tt.message {
+				t.Errorf("message: got %q, want %q", c.Message, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package collect
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificateInfo describes an X.509 certificate without its key material
+type CertificateInfo struct {
+	Subject   string
+	Issuer    string
+	DNSNames  []string `json:",omitempty"`
+	NotBefore metav1.Time
+	NotAfter  metav1.Time
+}
+
+// parseCertificates returns the certificates of a PEM bundle. Blocks which
+// are not certificates or can't be parsed are skipped.
+func parseCertificates(data []byte) []CertificateInfo {
+	certs := make([]CertificateInfo, 0)
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, CertificateInfo{
+			Subject:   c.Subject.String(),
+			Issuer:    c.Issuer.String(),
+			DNSNames:  c.DNSNames,
+			NotBefore: metav1.NewTime(c.NotBefore),
+			NotAfter:  metav1.NewTime(c.NotAfter),
+		})
+	}
+}
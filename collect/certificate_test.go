@@ -0,0 +1,85 @@
+package collect
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed certificate in PEM
+func testCertificate(t *testing.T, cn string, dnsNames ...string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestParseCertificates(t *testing.T) {
+	webhook := testCertificate(t, "webhook", "webhook.default.svc")
+	ca := testCertificate(t, "ca")
+	key := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")})
+	invalid := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("invalid")})
+	join := func(l ...[]byte) []byte {
+		var ret []byte
+		for _, b := range l {
+			ret = append(ret, b...)
+		}
+		return ret
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want []string
+	}{
+		{name: "empty"},
+		{name: "not PEM", data: []byte("certificate")},
+		{name: "one", data: webhook, want: []string{"CN=webhook"}},
+		{name: "bundle", data: join(webhook, []byte("\n"), ca), want: []string{"CN=webhook", "CN=ca"}},
+		{name: "other blocks are skipped", data: join(key, webhook), want: []string{"CN=webhook"}},
+		{name: "invalid certificates are skipped", data: join(invalid, ca), want: []string{"CN=ca"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs := parseCertificates(tt.data)
+			if certs == nil {
+				t.Fatal("got nil")
+			}
+			var subjects []string
+			for _, c := range certs {
+				subjects = append(subjects, c.Subject)
+				if c.Issuer != c.Subject {
+					t.Errorf("issuer: got %s, want %s", c.Issuer, c.Subject)
+				}
+				if c.NotBefore.UTC().Year() != 2024 || c.NotAfter.UTC().Year() != 2025 {
+					t.Errorf("validity: got %v to %v", c.NotBefore, c.NotAfter)
+				}
+			}
+			if !reflect.DeepEqual(subjects, tt.want) {
+				t.Errorf("got %v, want %v", subjects, tt.want)
+			}
+		})
+	}
+	if got := parseCertificates(webhook)[0].DNSNames; !reflect.DeepEqual(got, []string{"webhook.default.svc"}) {
+		t.Errorf("DNS names: got %v", got)
+	}
+}
@@ -1,15 +1,20 @@
 package collect
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Masterminds/semver"
-	inventory "github.com/neticdk-k8s/k8s-inventory"
 	"github.com/neticdk-k8s/k8s-inventory-client/detect"
-	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectCluster(cs *ck.Clientset, i *inventory.Inventory) error {
+func init() {
+	Register(NewCollector("cluster", nil, collectCluster))
+	Register(NewCollector("scs", nil, collectSCSMetadata))
+}
+
+func collectCluster(ctx context.Context, cl *Clients, i *Inventory) error {
+	cs := cl.Clientset
 	v, err := cs.Discovery().ServerVersion()
 	if err != nil {
 		return fmt.Errorf("getting server version: %v", err)
@@ -21,8 +26,8 @@ func collectCluster(cs *ck.Clientset, i *inventory.Inventory) error {
 	i.Cluster.GitCommit = v.GitCommit
 	i.Cluster.BuildDate = v.BuildDate
 
-	i.Cluster.KubernetesProvider = detect.DetectKubernetesProvider(cs)
-	i.Cluster.InfrastructureProvider = detect.DetectInfrastructureProvider(cs, i.Cluster.KubernetesProvider)
+	i.Cluster.KubernetesProvider = detect.DetectKubernetesProvider(ctx, cs)
+	i.Cluster.InfrastructureProvider = detect.DetectInfrastructureProvider(ctx, cs, i.Cluster.KubernetesProvider)
 
 	if i.Cluster.InfrastructureProvider == "docker" {
 		i.Cluster.KubernetesProvider = "kind"
@@ -31,8 +36,8 @@ func collectCluster(cs *ck.Clientset, i *inventory.Inventory) error {
 	return nil
 }
 
-func collectSCSMetadata(cs *ck.Clientset, i *inventory.Inventory) error {
-	cm, err := readConfigMapByName(cs, "netic-metadata-system", "cluster-id")
+func collectSCSMetadata(ctx context.Context, cl *Clients, i *Inventory) error {
+	cm, err := readConfigMapByName(ctx, cl.Clientset, "netic-metadata-system", "cluster-id")
 	if err != nil {
 		return err
 	}
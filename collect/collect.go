@@ -12,39 +12,74 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	inventory "github.com/neticdk-k8s/k8s-inventory"
 	"github.com/neticdk-k8s/k8s-inventory-client/collect/version"
 	"github.com/neticdk-k8s/k8s-inventory-client/config"
 	kubernetes "github.com/neticdk-k8s/k8s-inventory-client/kubernetes"
+	"github.com/neticdk-k8s/k8s-inventory-client/spool"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	jose "gopkg.in/go-jose/go-jose.v2"
+	ck "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // How often to collect
 const defaultCollectionInterval = "1h"
 
+// Minimum time between rebuilds from the informer cache
+const defaultCacheRefreshInterval = "30s"
+
+// How long a single collector may run
+const defaultCollectTimeout = "5m"
+
+// Maximum time between full uploads when uploading deltas
+const defaultDeltaResyncInterval = "24h"
+
 type InventoryCollection struct {
-	mu                 sync.RWMutex
-	inventory          *inventory.Inventory
-	collectionInterval string
-	uploadInventory    bool
-	impersonate        string
-	serverAPIEndpoint  string
-	tlsCrt             string
-	tlsKey             string
-	authEnabled        bool
-	signer             jose.Signer
-	metaData           *metaData
+	mu                    sync.RWMutex
+	current               atomic.Pointer[snapshot]
+	generation            atomic.Uint64
+	collectionInterval    string
+	cacheEnabled          bool
+	cacheRefreshInterval  string
+	collectors            []Collector
+	collectConcurrency    int
+	collectTimeout        time.Duration
+	certificateExpiryDays int
+	uploadInventory       bool
+	impersonate           string
+	serverAPIEndpoint     string
+	tlsCrt                string
+	tlsKey                string
+	authEnabled           bool
+	signer                jose.Signer
+	metaData              *metaData
+	spool                 *spool.Spool
+	spoolNotify           chan struct{}
+	retryBackoff          time.Duration
+	retryMaxBackoff       time.Duration
+	deltaUploads          bool
+	deltaResyncInterval   time.Duration
+	lastUpload            *inventoryState
+	readinessMaxUploadAge time.Duration
+	health                health
+	trigger               *trigger
+	triggerTokenFile      string
+	history               *history
+	feed                  *changeFeed
 }
 
 type metaData struct {
 	Updated  *time.Time              `json:"updated,omitempty"`
 	Cluster  *uploadResponseCluster  `json:"cluster,omitempty"`
 	MetaData *uploadResponseMetaData `json:"meta_data,omitempty"`
+	Spool    *spool.Status           `json:"spool,omitempty"`
 }
 
 type uploadResponseCluster struct {
@@ -79,18 +114,65 @@ type uploadResponse struct {
 	Cluster  uploadResponseCluster  `json:"cluster"`
 	MetaData uploadResponseMetaData `json:"meta_data"`
 	Message  string                 `json:"message,omitempty"`
+	// Resync asks for the next upload to be a full inventory
+	Resync bool `json:"resync,omitempty"`
+}
+
+type uploadResult struct {
+	ETag   string
+	Resync bool
 }
 
 func NewInventoryCollection(cfg config.Config) *InventoryCollection {
+	collectors := defaultRegistry.Collectors(
+		strings.Split(cfg.EnabledCollectors, ","),
+		strings.Split(cfg.DisabledCollectors, ","),
+	)
+	names := make([]string, 0, len(collectors))
+	for _, col := range collectors {
+		names = append(names, col.Name())
+	}
+	log.Info().Strs("collectors", names).Msg("enabled collectors")
+
 	i := &InventoryCollection{
-		collectionInterval: cfg.CollectionInterval,
-		uploadInventory:    cfg.UploadInventory,
-		impersonate:        cfg.Impersonate,
-		serverAPIEndpoint:  fmt.Sprintf("%s/api/v1/inventory", cfg.ServerAPIEndpoint),
-		tlsCrt:             cfg.TLSCrt,
-		tlsKey:             cfg.TLSKey,
-		authEnabled:        cfg.AuthEnabled,
-		metaData:           &metaData{},
+		collectionInterval:    cfg.CollectionInterval,
+		cacheEnabled:          cfg.CacheEnabled,
+		cacheRefreshInterval:  cfg.CacheRefreshInterval,
+		collectors:            collectors,
+		collectConcurrency:    max(cfg.CollectConcurrency, 1),
+		collectTimeout:        parseDuration(cfg.CollectTimeout, defaultCollectTimeout),
+		certificateExpiryDays: cfg.CertificateExpiryDays,
+		uploadInventory:       cfg.UploadInventory,
+		impersonate:           cfg.Impersonate,
+		serverAPIEndpoint:     fmt.Sprintf("%s/api/v1/inventory", cfg.ServerAPIEndpoint),
+		tlsCrt:                cfg.TLSCrt,
+		tlsKey:                cfg.TLSKey,
+		authEnabled:           cfg.AuthEnabled,
+		metaData:              &metaData{},
+		spoolNotify:           make(chan struct{}, 1),
+		retryBackoff:          parseDuration(cfg.UploadRetryBackoff, defaultUploadRetryBackoff),
+		retryMaxBackoff:       parseDuration(cfg.UploadRetryMaxBackoff, defaultUploadRetryMaxBackoff),
+		deltaUploads:          cfg.DeltaUploads,
+		deltaResyncInterval:   parseDuration(cfg.DeltaResyncInterval, defaultDeltaResyncInterval),
+		readinessMaxUploadAge: parseDuration(cfg.ReadinessMaxUploadAge, defaultReadinessMaxUploadAge),
+		trigger:               newTrigger(parseDuration(cfg.TriggerMinInterval, defaultTriggerMinInterval)),
+		triggerTokenFile:      cfg.TriggerTokenFile,
+	}
+	if cfg.SpoolDir != "" {
+		i.spool = newSpool(cfg)
+	}
+	if cfg.WatchBufferSize > 0 {
+		i.feed = newChangeFeed(cfg.WatchBufferSize)
+	}
+	if cfg.HistorySize > 0 {
+		h, err := newHistory(cfg.HistorySize, cfg.HistoryDir, parseDuration(cfg.HistoryInterval, defaultHistoryInterval))
+		if err != nil {
+			log.Error().Err(err).Str("dir", cfg.HistoryDir).Msg("creating history. History disabled.")
+		} else {
+			i.history = h
+			// Keep generations increasing across restarts
+			i.generation.Store(h.lastGeneration())
+		}
 	}
 	if !i.authEnabled {
 		log.Info().Msg("Authentication disabled")
@@ -144,9 +226,12 @@ func (c *InventoryCollection) refreshCertificates() {
 		go reschedule(2 * time.Minute)
 		return
 	}
+	c.mu.Lock()
 	c.signer = signer
+	c.mu.Unlock()
 
 	if len(certificates) > 0 {
+		certificateExpiry.Set(float64(certificates[0].NotAfter.Unix()))
 		d := time.Until(certificates[0].NotAfter)
 		go reschedule(d - (5 * time.Minute))
 	}
@@ -191,126 +276,462 @@ func readKeyAndCertificates(certFile, keyFile string) ([]*x509.Certificate, any,
 }
 
 func (c *InventoryCollection) Collect() {
-	r, err := time.ParseDuration(c.collectionInterval)
-	if err != nil {
-		log.Warn().Err(err).Str("interval", c.collectionInterval).Msg("parsing refresh interval")
-		r, err = time.ParseDuration(defaultCollectionInterval)
-		if err != nil {
-			log.Fatal().Err(err).Str("interval", defaultCollectionInterval).Msg("parsing refresh interval")
-		}
-	}
+	r := parseDuration(c.collectionInterval, defaultCollectionInterval)
 
 	sleepNext := func() {
 		t := time.Now().Add(r)
 		log.Info().Msgf("next iteration in %v at %v", r, t.Local().Format(time.DateTime))
-		time.Sleep(r)
+		c.checkIn(r)
+		select {
+		case <-time.After(r):
+		case <-c.trigger.ch:
+			log.Info().Msg("collection triggered")
+		}
+	}
+
+	ctx := context.Background()
+
+	if c.spool != nil {
+		go c.retrySpooled(ctx)
+	}
+
+	if c.cacheEnabled {
+		c.collectCached(ctx, r)
+		return
 	}
 
 	log.Info().Msg("entering inventory collection loop")
 	for {
-		ctx := context.Background()
-
-		c.inventory = inventory.NewInventory()
-		c.inventory.CollectionSucceeded = true
-		c.inventory.ClientVersion = version.VERSION
-		c.inventory.ClientCommit = version.COMMIT
+		waiters := c.trigger.take()
+		start := time.Now()
 		cs, client, err := kubernetes.CreateK8SClient(c.impersonate)
 		if err != nil {
 			log.Error().Err(err).Msg("creating clientset")
-			c.inventory.CollectionSucceeded = false
-			c.inventory.CollectionErrors = append(c.inventory.CollectionErrors, err.Error())
+			c.collectionFailed(err)
+			c.trigger.done(waiters, c.result(start, nil))
 			sleepNext()
 			continue
 		}
 
-		log.Debug().Str("collect", "cluster").Msg("")
-		c.handleError(collectCluster(cs, c.inventory))
+		c.collect(ctx, cs, client)
+		err = c.maybeUpload()
+		c.trigger.done(waiters, c.result(start, err))
 
-		log.Debug().Str("collect", "scs").Msg("")
-		c.handleError(collectSCSMetadata(cs, c.inventory))
+		sleepNext()
+	}
+}
 
-		log.Debug().Str("collect", "namespace").Msg("")
-		c.handleError(collectNamespaces(cs, c.inventory))
+// collectCached keeps an informer cache up to date from watch events and
+// rebuilds the inventory from it whenever something changes, at most once
+// every cacheRefreshInterval. The inventory is uploaded every interval.
+// Discovery and custom resources are not watched and are read again only
+// when the inventory is uploaded.
+func (c *InventoryCollection) collectCached(ctx context.Context, interval time.Duration) {
+	refresh := parseDuration(c.cacheRefreshInterval, defaultCacheRefreshInterval)
 
-		log.Debug().Str("collect", "node").Msg("")
-		c.handleError(collectNodes(cs, c.inventory))
+	var (
+		cs        *ck.Clientset
+		client    client.Client
+		changed   = make(chan struct{}, 1)
+		responses = kubernetes.NewResponseCache()
+	)
+	for {
+		var (
+			ca  cache.Cache
+			err error
+		)
+		// Each attempt gets its own cache, which is stopped if the attempt
+		// fails. Syncing fails after collectTimeout if a kind can't be listed.
+		c.checkIn(2 * c.collectTimeout)
+		cacheCtx, cancel := context.WithCancel(ctx)
+		cs, client, ca, err = kubernetes.CreateCachedK8SClient(cacheCtx, c.impersonate, c.collectTimeout, responses)
+		if err == nil {
+			err = watchCache(cacheCtx, ca, changed, c.collectTimeout)
+		}
+		if err == nil {
+			break
+		}
+		cancel()
+		log.Error().Err(err).Msg("creating cached client")
+		c.collectionFailed(err)
+		log.Info().Msgf("retrying in %v", interval)
+		c.checkIn(interval)
+		time.Sleep(interval)
+	}
 
-		log.Debug().Str("collect", "storage").Msg("")
-		c.handleError(collectStorage(cs, c.inventory))
+	log.Info().Dur("refresh", refresh).Msg("entering cached inventory collection loop")
+	var (
+		last    time.Time
+		rebuild <-chan time.Time
+	)
+	collectAndUpload := func() {
+		responses.Reset()
+		c.collectAndUpload(ctx, cs, client)
+		// Changes seen so far are part of the new inventory
+		last, rebuild = time.Now(), nil
+	}
+	collectAndUpload()
 
-		log.Debug().Str("collect", "network_policy").Msg("")
-		c.handleError(collectNetworkPolicies(cs, c.inventory))
+	upload := time.NewTicker(interval)
+	defer upload.Stop()
+	for {
+		c.checkIn(interval)
+		select {
+		case <-upload.C:
+			collectAndUpload()
+		case <-c.trigger.ch:
+			log.Info().Msg("collection triggered")
+			collectAndUpload()
+		case <-changed:
+			// Rebuilds wait until refresh has passed since the last one
+			if rebuild == nil {
+				rebuild = time.After(time.Until(last.Add(refresh)))
+			}
+		case <-rebuild:
+			log.Debug().Msg("cache changed, rebuilding inventory")
+			c.collect(ctx, cs, client)
+			last, rebuild = time.Now(), nil
+		}
+	}
+}
 
-		log.Debug().Str("collect", "components").Msg("")
-		c.handleError(collectCustomResources(cs, c.inventory))
+// collectAndUpload collects and uploads the inventory and reports the result
+// to those waiting for a triggered collection
+func (c *InventoryCollection) collectAndUpload(ctx context.Context, cs *ck.Clientset, client client.Client) {
+	waiters := c.trigger.take()
+	start := time.Now()
+	c.collect(ctx, cs, client)
+	err := c.maybeUpload()
+	c.trigger.done(waiters, c.result(start, err))
+}
+
+// collect builds a new inventory and publishes it when all collectors are done
+func (c *InventoryCollection) collect(ctx context.Context, cs *ck.Clientset, client client.Client) *Inventory {
+	c.checkIn(c.collectBudget())
+	i := newInventory()
+
+	resources, err := discoverAPIResources(cs)
+	handleError(i, err)
+
+	cl := &Clients{
+		Clientset:             cs,
+		Client:                client,
+		APIResources:          resources,
+		CertificateExpiryDays: c.certificateExpiryDays,
+	}
+	for _, err := range c.runCollectors(ctx, cl, applicableCollectors(c.collectors, resources), i) {
+		handleError(i, err)
+	}
+	c.publish(i)
+	observeInventory(i)
+	c.collected()
+	return i
+}
 
-		log.Debug().Str("collect", "workload").Msg("")
-		c.handleError(collectWorkloads(ctx, cs, client, c.inventory))
+func newInventory() *Inventory {
+	i := NewInventory()
+	i.CollectionSucceeded = true
+	i.ClientVersion = version.VERSION
+	i.ClientCommit = version.COMMIT
+	return i
+}
 
-		if c.uploadInventory {
-			if err := c.upload(); err != nil {
-				log.Error().Stack().Err(err).Msg("uplading inventory")
+// runCollectors runs collectors concurrently, at most collectConcurrency at a
+// time, and returns their errors once all of them have returned. Each
+// collector gets its own deadline of collectTimeout. Linkers are run in
+// order afterwards.
+func (c *InventoryCollection) runCollectors(ctx context.Context, cl *Clients, collectors []Collector, i *Inventory) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, c.collectConcurrency)
+	)
+	for _, col := range collectors {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := c.runCollector(ctx, cl, col, i); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
 			}
+		}()
+	}
+	wg.Wait()
+	for _, col := range collectors {
+		if l, ok := col.(Linker); ok {
+			l.Link(i)
 		}
+	}
+	return errs
+}
 
-		sleepNext()
+func (c *InventoryCollection) runCollector(ctx context.Context, cl *Clients, col Collector, i *Inventory) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, c.collectTimeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	c.collectorStarted(col.Name(), deadline)
+	defer c.collectorDone(col.Name())
+
+	log.Debug().Str("collect", col.Name()).Msg("")
+	start := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		defer func() { observeCollector(col.Name(), time.Since(start), err) }()
+		if err == nil {
+			log.Debug().Str("collect", col.Name()).Dur("duration", time.Since(start)).Msg("done")
+			return
+		}
+		err = CollectorError{
+			Collector: col.Name(),
+			Timeout:   errors.Is(ctx.Err(), context.DeadlineExceeded),
+			Duration:  time.Since(start).String(),
+			Message:   err.Error(),
+			err:       err,
+		}
+	}()
+
+	return col.Collect(ctx, cl, i)
+}
+
+func (c *InventoryCollection) maybeUpload() error {
+	if !c.uploadInventory {
+		return nil
+	}
+	err := c.upload()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("uplading inventory")
+	}
+	return err
+}
+
+func (c *InventoryCollection) collectionFailed(err error) {
+	i := newInventory()
+	i.CollectionSucceeded = false
+	i.CollectionErrors = append(i.CollectionErrors, err.Error())
+	c.publish(i)
+}
+
+func parseDuration(s string, def string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Warn().Err(err).Str("interval", s).Msg("parsing interval")
+		d, err = time.ParseDuration(def)
+		if err != nil {
+			log.Fatal().Err(err).Str("interval", def).Msg("parsing interval")
+		}
 	}
+	return d
 }
 
 func (c *InventoryCollection) upload() error {
 	log.Info().Msg("uploading inventory")
 
+	snap := c.snapshot()
+	if snap == nil {
+		return errors.New("no inventory collected yet")
+	}
+	data := snap.JSON
 	var (
-		payload     []byte
-		err         error
-		contentType string = "application/json; charset=UTF-8"
+		flat *flattenedInventory
+		err  error
 	)
+	if c.deltaUploads {
+		if flat, err = flattenInventory(data); err != nil {
+			log.Warn().Err(err).Msg("flattening inventory, uploading full inventory")
+		}
+	}
 
-	payload, err = json.Marshal(c.inventory)
-	if err != nil {
-		return errors.Wrap(err, "marshaling inventory")
+	// Keep uploads in order while older ones are waiting to be retried
+	if c.spool != nil && c.spool.Status().Entries > 0 {
+		c.lastUpload = nil
+		return c.spoolPayload(data)
 	}
 
-	if c.authEnabled {
-		jws, err := c.signer.Sign(payload)
-		if err != nil {
-			return errors.Wrap(err, "signing inventory")
+	if flat != nil && c.lastUpload != nil && time.Since(c.lastUpload.Created) < c.deltaResyncInterval {
+		err := c.uploadDelta(flat)
+		if err == nil {
+			return nil
 		}
-		payload = []byte(jws.FullSerialize())
-		contentType = "application/jose+json"
+		log.Warn().Err(err).Msg("delta upload not accepted, uploading full inventory")
 	}
+	c.lastUpload = nil
 
+	contentType, payload, err := c.payload(data)
+	if err != nil {
+		return err
+	}
+	res, err := c.send(http.MethodPut, contentType, payload, "")
+	if err != nil && c.spool != nil && isRetryable(err) {
+		log.Error().Err(err).Msg("uploading inventory failed, spooling for retry")
+		return c.spoolUpload(contentType, payload)
+	}
+	if err == nil && flat != nil && !res.Resync {
+		if res.ETag != "" {
+			flat.state.Hash = res.ETag
+		}
+		c.lastUpload = flat.state
+	}
+	return err
+}
+
+// uploadDelta uploads the changes since the last accepted inventory. The
+// server answers with an error or asks for a resync if it does not have the
+// base of the delta.
+func (c *InventoryCollection) uploadDelta(flat *flattenedInventory) error {
+	d := flat.deltaFrom(c.lastUpload)
+	data, err := json.Marshal(d)
+	if err != nil {
+		return errors.Wrap(err, "marshaling delta")
+	}
+	contentType, payload, err := c.payload(data)
+	if err != nil {
+		return err
+	}
+	res, err := c.send(http.MethodPatch, contentType, payload, c.lastUpload.Hash)
+	if err != nil {
+		return err
+	}
+	if res.Resync {
+		return fmt.Errorf("server requested a full resync")
+	}
+	log.Info().
+		Int("added", len(d.Added)).
+		Int("modified", len(d.Modified)).
+		Int("removed", len(d.Removed)).
+		Bool("empty", d.empty()).
+		Msg("uploaded inventory delta")
+
+	// Keep the time of the last full upload so resyncs happen regularly
+	flat.state.Created = c.lastUpload.Created
+	if res.ETag != "" {
+		flat.state.Hash = res.ETag
+	}
+	c.lastUpload = flat.state
+	return nil
+}
+
+func (c *InventoryCollection) spoolPayload(data []byte) error {
+	contentType, payload, err := c.payload(data)
+	if err != nil {
+		return err
+	}
+	return c.spoolUpload(contentType, payload)
+}
+
+// payload returns the gzipped, and optionally signed, JSON data
+func (c *InventoryCollection) payload(data []byte) (string, []byte, error) {
+	contentType, payload, err := c.sign(data)
+	if err != nil {
+		return "", nil, err
+	}
+	payload, err = compress(payload)
+	if err != nil {
+		return "", nil, err
+	}
+	return contentType, payload, nil
+}
+
+// sign returns the data as a JWS if authentication is enabled
+func (c *InventoryCollection) sign(data []byte) (string, []byte, error) {
+	if !c.authEnabled {
+		return "application/json; charset=UTF-8", data, nil
+	}
+	c.mu.RLock()
+	signer := c.signer
+	c.mu.RUnlock()
+	if signer == nil {
+		return "", nil, fmt.Errorf("signing inventory: no signing key loaded")
+	}
+	jws, err := signer.Sign(data)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "signing inventory")
+	}
+	return "application/jose+json", []byte(jws.FullSerialize()), nil
+}
+
+func compress(data []byte) ([]byte, error) {
 	var gzippedBuf bytes.Buffer
 	gzipWriter := gzip.NewWriter(&gzippedBuf)
-	if _, err = gzipWriter.Write(bytes.NewBuffer(payload).Bytes()); err != nil {
-		return errors.Wrap(err, "compressiong payload")
+	if _, err := gzipWriter.Write(data); err != nil {
+		return nil, errors.Wrap(err, "compressiong payload")
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, errors.Wrap(err, "compressiong payload")
+	}
+	return gzippedBuf.Bytes(), nil
+}
+
+// SigningEnabled tells if uploaded and written inventories are signed
+func (c *InventoryCollection) SigningEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.authEnabled && c.signer != nil
+}
+
+// CollectOnce runs the collectors once and returns the inventory
+func (c *InventoryCollection) CollectOnce(ctx context.Context, cs *ck.Clientset, client client.Client) *Inventory {
+	return c.collect(ctx, cs, client)
+}
+
+// WriteInventory writes the last collected inventory to w as JSON, signed if
+// authentication is enabled and gzipped if gzipped is true
+func (c *InventoryCollection) WriteInventory(w io.Writer, gzipped bool) error {
+	snap := c.snapshot()
+	if snap == nil {
+		return errors.New("no inventory collected yet")
 	}
-	if err = gzipWriter.Close(); err != nil {
-		return errors.Wrap(err, "compressiong payload")
+	_, data, err := c.sign(snap.JSON)
+	if err != nil {
+		return err
+	}
+	if gzipped {
+		if data, err = compress(data); err != nil {
+			return err
+		}
 	}
+	_, err = w.Write(data)
+	return err
+}
 
-	req, err := http.NewRequest("PUT", c.serverAPIEndpoint, &gzippedBuf)
+// send uploads a gzipped payload to the inventory server. Full inventories
+// are sent with PUT and deltas with PATCH, conditional on the ETag or hash of
+// the inventory they are based on.
+func (c *InventoryCollection) send(method, contentType string, payload []byte, ifMatch string) (*uploadResult, error) {
+	req, err := http.NewRequest(method, c.serverAPIEndpoint, bytes.NewReader(payload))
 	if err != nil {
-		return errors.Wrap(err, "creating request")
+		return nil, errors.Wrap(err, "creating request")
 	}
 
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("Content-Encoding", "gzip")
-	client := &http.Client{}
+	if ifMatch != "" {
+		req.Header.Set("If-Match", entityTag(ifMatch))
+	}
+	client := &http.Client{Timeout: uploadTimeout}
 	res, err := client.Do(req)
 	if err != nil {
-		return errors.Wrap(err, "sending request")
+		observeUpload(method, len(payload), 0, err)
+		return nil, errors.Wrap(err, "sending request")
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		observeUpload(method, len(payload), res.StatusCode, &errUpload{StatusCode: res.StatusCode})
 		body, err := io.ReadAll(res.Body)
 		if err != nil {
 			log.Error().Err(err).Int("status", res.StatusCode).Msg("reading response")
-			return errors.Wrap(err, "reading response")
+			return nil, errors.Wrap(err, "reading response")
 		}
 		log.Error().Int("status", res.StatusCode).Str("body", string(body)).Msg("")
-		return errors.New("upload failed")
+		return nil, &errUpload{StatusCode: res.StatusCode}
 	}
 
 	c.mu.Lock()
@@ -318,40 +739,44 @@ func (c *InventoryCollection) upload() error {
 
 	metaDataResponse := &uploadResponse{}
 	if err := json.NewDecoder(res.Body).Decode(metaDataResponse); err != nil {
-		return errors.Wrap(err, "unmarshal response")
+		observeUpload(method, len(payload), res.StatusCode, err)
+		return nil, errors.Wrap(err, "unmarshal response")
 	}
+	observeUpload(method, len(payload), res.StatusCode, nil)
 	t := time.Now()
 	c.metaData.Updated = &t
 	c.metaData.Cluster = &metaDataResponse.Cluster
 	c.metaData.MetaData = &metaDataResponse.MetaData
 
-	log.Info().Str("fqdn", c.inventory.Cluster.Name).Int("status", res.StatusCode).Msg("uploaded inventory")
+	log.Info().Str("cluster", metaDataResponse.Cluster.Name).Int("status", res.StatusCode).Msg("uploaded inventory")
 
-	return nil
-}
-
-func (c *InventoryCollection) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	defer r.Body.Close()
-	err := json.NewEncoder(w).Encode(c.inventory)
-	if err != nil {
-		http.Error(w, errHTTPInternalError.JSON(), http.StatusInternalServerError)
-		return
-	}
+	return &uploadResult{ETag: res.Header.Get("ETag"), Resync: metaDataResponse.Resync}, nil
 }
 
 func (c *InventoryCollection) ServeHTTPMeta(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	err := json.NewEncoder(w).Encode(c.metaData)
+	c.mu.RLock()
+	md := *c.metaData
+	c.mu.RUnlock()
+	if c.spool != nil {
+		st := c.spool.Status()
+		md.Spool = &st
+	}
+	err := json.NewEncoder(w).Encode(md)
 	if err != nil {
 		http.Error(w, errHTTPInternalError.JSON(), http.StatusInternalServerError)
 		return
 	}
 }
 
-func (c *InventoryCollection) handleError(err error) {
+func handleError(i *Inventory, err error) {
 	if err != nil {
-		c.inventory.CollectionSucceeded = false
-		c.inventory.CollectionErrors = append(c.inventory.CollectionErrors, err.Error())
+		i.CollectionSucceeded = false
+		i.CollectionErrors = append(i.CollectionErrors, err.Error())
+		var colErr CollectorError
+		if errors.As(err, &colErr) {
+			i.CollectorErrors = append(i.CollectorErrors, colErr)
+		}
 		log.Error().Stack().Err(err).Msg("")
 	}
 }
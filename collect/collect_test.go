@@ -0,0 +1,111 @@
+package collect
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunCollectors(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	busy := func(name string, fill func(i *Inventory)) Collector {
+		return NewCollector(name, nil, func(ctx context.Context, cl *Clients, i *Inventory) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			fill(i)
+			return nil
+		})
+	}
+	var linked []string
+	collectors := []Collector{
+		NewCollector("blocking", nil, func(ctx context.Context, cl *Clients, i *Inventory) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+		NewCollector("panicking", nil, func(ctx context.Context, cl *Clients, i *Inventory) error {
+			panic("boom")
+		}),
+		busy("priority_class", func(i *Inventory) { i.PriorityClasses = []*PriorityClass{NewPriorityClass()} }),
+		busy("namespace", func(i *Inventory) { i.Namespaces = []*Namespace{} }),
+		WithLink(busy("service", func(i *Inventory) { i.Networking.Services = []*Service{NewService()} }), func(i *Inventory) {
+			linked = append(linked, "service")
+			if i.PriorityClasses == nil || i.Namespaces == nil {
+				t.Error("linked before all collectors were done")
+			}
+		}),
+		WithLink(busy("ingress", func(i *Inventory) { i.Networking.Ingresses = []*Ingress{} }), func(i *Inventory) {
+			linked = append(linked, "ingress")
+		}),
+	}
+
+	c := &InventoryCollection{collectConcurrency: 2, collectTimeout: 100 * time.Millisecond}
+	i := newInventory()
+	start := time.Now()
+	for _, err := range c.runCollectors(context.Background(), &Clients{}, collectors, i) {
+		handleError(i, err)
+	}
+	if d := time.Since(start); d > 5*c.collectTimeout {
+		t.Errorf("took %v", d)
+	}
+
+	if m := maxRunning.Load(); m > 2 {
+		t.Errorf("got %d collectors running at once, want at most 2", m)
+	}
+	if i.CollectionSucceeded {
+		t.Error("collection succeeded")
+	}
+	if len(i.CollectionErrors) != 2 || len(i.CollectorErrors) != 2 {
+		t.Fatalf("got errors %v and %+v", i.CollectionErrors, i.CollectorErrors)
+	}
+	errs := make(map[string]CollectorError)
+	for n, e := range i.CollectorErrors {
+		errs[e.Collector] = e
+		if i.CollectionErrors[n] != e.Error() {
+			t.Errorf("got %q, want %q", i.CollectionErrors[n], e.Error())
+		}
+	}
+	if e := errs["blocking"]; !e.Timeout || e.Duration == "" {
+		t.Errorf("blocking: got %+v", e)
+	}
+	if e := errs["panicking"]; e.Timeout || !strings.Contains(e.Message, "panic: boom") {
+		t.Errorf("panicking: got %+v", e)
+	}
+	if len(i.PriorityClasses) != 1 || i.Namespaces == nil || len(i.Networking.Services) != 1 || i.Networking.Ingresses == nil {
+		t.Error("sections of the other collectors not filled in")
+	}
+	if want := []string{"service", "ingress"}; strings.Join(linked, ",") != strings.Join(want, ",") {
+		t.Errorf("linked %v, want %v", linked, want)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.health.running) != 0 {
+		t.Errorf("collectors still running: %v", c.health.running)
+	}
+}
+
+func TestRunCollectorsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &InventoryCollection{collectConcurrency: 1, collectTimeout: time.Minute}
+	errs := c.runCollectors(ctx, &Clients{}, []Collector{
+		NewCollector("blocking", nil, func(ctx context.Context, cl *Clients, i *Inventory) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	}, newInventory())
+	if len(errs) != 1 {
+		t.Fatalf("got %v", errs)
+	}
+	if e, ok := errs[0].(CollectorError); !ok || e.Timeout {
+		t.Errorf("cancelled collector recorded as timeout: %+v", errs[0])
+	}
+}
@@ -0,0 +1,152 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	ck "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Clients holds what collectors need to talk to the cluster
+type Clients struct {
+	Clientset *ck.Clientset
+	Client    client.Client
+	// APIResources contains the group/version/resource of every resource
+	// served by the cluster, e.g. "velero.io/v1/backups"
+	APIResources map[string]bool
+	// CertificateExpiryDays is how soon certificates must expire to be
+	// listed as expiring
+	CertificateExpiryDays int
+}
+
+// Collector collects a part of the inventory
+type Collector interface {
+	// Name identifies the collector in logs and configuration
+	Name() string
+	// Resources lists the API resources, as group/version/resource, that
+	// must be served by the cluster for the collector to run
+	Resources() []string
+	Collect(ctx context.Context, cl *Clients, i *Inventory) error
+}
+
+type collectorFunc struct {
+	name      string
+	resources []string
+	collect   func(ctx context.Context, cl *Clients, i *Inventory) error
+}
+
+func (c *collectorFunc) Name() string        { return c.name }
+func (c *collectorFunc) Resources() []string { return c.resources }
+func (c *collectorFunc) Collect(ctx context.Context, cl *Clients, i *Inventory) error {
+	return c.collect(ctx, cl, i)
+}
+
+// NewCollector creates a Collector from a function
+func NewCollector(name string, resources []string, fn func(ctx context.Context, cl *Clients, i *Inventory) error) Collector {
+	return &collectorFunc{name: name, resources: resources, collect: fn}
+}
+
+// Linker is implemented by collectors that relate what they collected to
+// other parts of the inventory, e.g. services to the workloads they select.
+// Link is called when all collectors are done.
+type Linker interface {
+	Link(i *Inventory)
+}
+
+type linkedCollector struct {
+	Collector
+	link func(i *Inventory)
+}
+
+func (c *linkedCollector) Link(i *Inventory) { c.link(i) }
+
+// WithLink returns c with fn as its Link
+func WithLink(c Collector, fn func(i *Inventory)) Collector {
+	return &linkedCollector{Collector: c, link: fn}
+}
+
+// Registry holds collectors in the order they were registered
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+var defaultRegistry = &Registry{}
+
+// Register adds a collector to the default registry
+func Register(c Collector) {
+	defaultRegistry.Register(c)
+}
+
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.collectors {
+		if e.Name() == c.Name() {
+			panic(fmt.Sprintf("collector %q registered twice", c.Name()))
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// Collectors returns the registered collectors that are enabled. If enabled
+// is empty all collectors not in disabled are returned.
+func (r *Registry) Collectors(enabled, disabled []string) []Collector {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	en := toSet(enabled)
+	dis := toSet(disabled)
+	ret := make([]Collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		if (len(en) > 0 && !en[c.Name()]) || dis[c.Name()] {
+			continue
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+// applicableCollectors returns the collectors whose required resources are
+// all served by the cluster
+func applicableCollectors(collectors []Collector, resources map[string]bool) []Collector {
+	ret := make([]Collector, 0, len(collectors))
+collectors:
+	for _, c := range collectors {
+		for _, r := range c.Resources() {
+			if !resources[r] {
+				continue collectors
+			}
+		}
+		ret = append(ret, c)
+	}
+	return ret
+}
+
+// discoverAPIResources returns the group/version/resource of all resources
+// served by the cluster. The result may be partial if err is not nil.
+func discoverAPIResources(cs *ck.Clientset) (map[string]bool, error) {
+	resourceMap := make(map[string]bool)
+	_, rl, err := cs.Discovery().ServerGroupsAndResources()
+	for _, l := range rl {
+		for _, r := range l.APIResources {
+			resourceMap[l.GroupVersion+"/"+r.Name] = true
+		}
+	}
+	if err != nil {
+		return resourceMap, fmt.Errorf("discovering API resources: %v", err)
+	}
+	return resourceMap, nil
+}
+
+func toSet(l []string) map[string]bool {
+	s := make(map[string]bool)
+	for _, e := range l {
+		if e = strings.TrimSpace(e); e != "" {
+			s[e] = true
+		}
+	}
+	return s
+}
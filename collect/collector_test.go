@@ -0,0 +1,94 @@
+package collect
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func testCollector(name string, resources ...string) Collector {
+	return NewCollector(name, resources, func(ctx context.Context, cl *Clients, i *Inventory) error { return nil })
+}
+
+func collectorNames(collectors []Collector) []string {
+	ret := make([]string, 0, len(collectors))
+	for _, c := range collectors {
+		ret = append(ret, c.Name())
+	}
+	return ret
+}
+
+func TestRegistryCollectors(t *testing.T) {
+	r := &Registry{}
+	for _, name := range []string{"node", "workload", "velero", "flux"} {
+		r.Register(testCollector(name))
+	}
+	tests := []struct {
+		name              string
+		enabled, disabled []string
+		want              []string
+	}{
+		{name: "all", want: []string{"node", "workload", "velero", "flux"}},
+		{name: "enabled", enabled: []string{"flux", "node"}, want: []string{"node", "flux"}},
+		{name: "disabled", disabled: []string{"velero"}, want: []string{"node", "workload", "flux"}},
+		{name: "enabled and disabled", enabled: []string{"node", "velero"}, disabled: []string{"velero"}, want: []string{"node"}},
+		{name: "spaces are trimmed", enabled: []string{" node", "flux "}, want: []string{"node", "flux"}},
+		{name: "empty names are ignored", enabled: []string{""}, disabled: []string{" "}, want: []string{"node", "workload", "velero", "flux"}},
+		{name: "unknown", enabled: []string{"unknown"}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collectorNames(r.Collectors(tt.enabled, tt.disabled)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryRegisterTwice(t *testing.T) {
+	r := &Registry{}
+	r.Register(testCollector("node"))
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice did not panic")
+		}
+	}()
+	r.Register(testCollector("node"))
+}
+
+func TestApplicableCollectors(t *testing.T) {
+	collectors := []Collector{
+		testCollector("node"),
+		testCollector("velero", "velero.io/v1/backups", "velero.io/v1/schedules"),
+		testCollector("vertical_pod_autoscaler", "autoscaling.k8s.io/v1/verticalpodautoscalers"),
+	}
+	tests := []struct {
+		name      string
+		resources map[string]bool
+		want      []string
+	}{
+		{name: "nothing served", want: []string{"node"}},
+		{
+			name:      "all served",
+			resources: map[string]bool{"velero.io/v1/backups": true, "velero.io/v1/schedules": true, "autoscaling.k8s.io/v1/verticalpodautoscalers": true},
+			want:      []string{"node", "velero", "vertical_pod_autoscaler"},
+		},
+		{
+			name:      "some served",
+			resources: map[string]bool{"velero.io/v1/backups": true, "autoscaling.k8s.io/v1/verticalpodautoscalers": true},
+			want:      []string{"node", "vertical_pod_autoscaler"},
+		},
+		{
+			name:      "other version served",
+			resources: map[string]bool{"autoscaling.k8s.io/v1beta2/verticalpodautoscalers": true},
+			want:      []string{"node"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collectorNames(applicableCollectors(collectors, tt.resources)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
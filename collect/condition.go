@@ -0,0 +1,36 @@
+package collect
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition is a status condition as reported by a controller
+type Condition struct {
+	Type               string
+	Status             string
+	Reason             string      `json:",omitempty"`
+	Message            string      `json:",omitempty"`
+	LastTransitionTime metav1.Time `json:",omitempty"`
+}
+
+// Reference refers to an object. APIGroup is empty for the core group.
+type Reference struct {
+	APIGroup  string `json:",omitempty"`
+	Kind      string `json:",omitempty"`
+	Namespace string `json:",omitempty"`
+	Name      string
+}
+
+func conditions(l []metav1.Condition) []Condition {
+	ret := make([]Condition, 0, len(l))
+	for _, c := range l {
+		ret = append(ret, Condition{
+			Type:               c.Type,
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime,
+		})
+	}
+	return ret
+}
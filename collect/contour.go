@@ -0,0 +1,158 @@
+package collect
+
+import (
+	"context"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	contourapi "github.com/projectcontour/contour/apis/projectcontour/v1"
+	ck "k8s.io/client-go/kubernetes"
+)
+
+func init() {
+	Register(NewCollector("contour", []string{"projectcontour.io/v1/httpproxies"}, collectContour))
+}
+
+type HTTPProxy struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 HTTPProxySpec   `json:"spec"`
+	Status               HTTPProxyStatus `json:"status"`
+}
+
+type HTTPProxySpec struct {
+	IngressClassName string `json:",omitempty"`
+	// FQDN is only set on root proxies
+	FQDN     string        `json:",omitempty"`
+	TLS      *HTTPProxyTLS `json:",omitempty"`
+	Includes []Reference
+	Routes   []HTTPProxyRoute
+	// TCPProxy lists the services of the TCP proxy, if any
+	TCPProxy []RouteBackend `json:",omitempty"`
+}
+
+type HTTPProxyTLS struct {
+	SecretName             string `json:",omitempty"`
+	MinimumProtocolVersion string `json:",omitempty"`
+	Passthrough            bool
+	ClientValidation       bool
+}
+
+type HTTPProxyRoute struct {
+	// Prefixes, exact paths and regular expressions matched
+	Paths          []string
+	PermitInsecure bool
+	Services       []RouteBackend
+}
+
+type HTTPProxyStatus struct {
+	CurrentStatus string
+	Description   string
+	LoadBalancer  []LoadBalancerIngress
+	Conditions    []Condition
+}
+
+func NewHTTPProxy() *HTTPProxy {
+	return &HTTPProxy{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "HTTPProxy",
+			APIGroup:     "projectcontour.io",
+			APIVersion:   "v1",
+			ResourceType: "httpproxies",
+		},
+	}
+}
+
+func collectContour(ctx context.Context, cl *Clients, i *Inventory) error {
+	proxies, err := collectHTTPProxies(ctx, cl.Clientset)
+	i.Networking.HTTPProxies = proxies
+	return err
+}
+
+func collectHTTPProxies(ctx context.Context, cs *ck.Clientset) ([]*HTTPProxy, error) {
+	proxies := make([]*HTTPProxy, 0)
+	list := &contourapi.HTTPProxyList{}
+	if found, err := listCustomResources(ctx, cs, "/apis/projectcontour.io/v1/httpproxies", list); !found {
+		return proxies, err
+	}
+	for _, o := range list.Items {
+		proxies = append(proxies, collectHTTPProxy(o))
+	}
+	return proxies, nil
+}
+
+func collectHTTPProxy(o contourapi.HTTPProxy) *HTTPProxy {
+	r := NewHTTPProxy()
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+
+	r.Spec.IngressClassName = o.Spec.IngressClassName
+	if vh := o.Spec.VirtualHost; vh != nil {
+		r.Spec.FQDN = vh.Fqdn
+		if vh.TLS != nil {
+			r.Spec.TLS = &HTTPProxyTLS{
+				SecretName:             vh.TLS.SecretName,
+				MinimumProtocolVersion: vh.TLS.MinimumProtocolVersion,
+				Passthrough:            vh.TLS.Passthrough,
+				ClientValidation:       vh.TLS.ClientValidation != nil,
+			}
+		}
+	}
+	r.Spec.Includes = make([]Reference, 0, len(o.Spec.Includes))
+	for _, inc := range o.Spec.Includes {
+		namespace := inc.Namespace
+		if namespace == "" {
+			namespace = o.Namespace
+		}
+		r.Spec.Includes = append(r.Spec.Includes, Reference{APIGroup: "projectcontour.io", Kind: "HTTPProxy", Namespace: namespace, Name: inc.Name})
+	}
+	r.Spec.Routes = make([]HTTPProxyRoute, 0, len(o.Spec.Routes))
+	for _, route := range o.Spec.Routes {
+		hr := HTTPProxyRoute{
+			Paths:          make([]string, 0),
+			PermitInsecure: route.PermitInsecure,
+			Services:       httpProxyServices(o.Namespace, route.Services),
+		}
+		for _, c := range route.Conditions {
+			switch {
+			case c.Prefix != "":
+				hr.Paths = append(hr.Paths, c.Prefix)
+			case c.Exact != "":
+				hr.Paths = append(hr.Paths, c.Exact)
+			case c.Regex != "":
+				hr.Paths = append(hr.Paths, c.Regex)
+			}
+		}
+		r.Spec.Routes = append(r.Spec.Routes, hr)
+	}
+	if o.Spec.TCPProxy != nil {
+		r.Spec.TCPProxy = httpProxyServices(o.Namespace, o.Spec.TCPProxy.Services)
+	}
+
+	r.Status.CurrentStatus = o.Status.CurrentStatus
+	r.Status.Description = o.Status.Description
+	r.Status.LoadBalancer = make([]LoadBalancerIngress, 0, len(o.Status.LoadBalancer.Ingress))
+	for _, lb := range o.Status.LoadBalancer.Ingress {
+		r.Status.LoadBalancer = append(r.Status.LoadBalancer, LoadBalancerIngress{IP: lb.IP, Hostname: lb.Hostname})
+	}
+	cs := make([]contourapi.Condition, 0, len(o.Status.Conditions))
+	for _, c := range o.Status.Conditions {
+		cs = append(cs, c.Condition)
+	}
+	r.Status.Conditions = conditions(cs)
+	return r
+}
+
+func httpProxyServices(namespace string, l []contourapi.Service) []RouteBackend {
+	ret := make([]RouteBackend, 0, len(l))
+	for _, s := range l {
+		b := RouteBackend{
+			Reference: Reference{Kind: "Service", Namespace: namespace, Name: s.Name},
+			Port:      int32(s.Port),
+		}
+		if s.Weight != 0 {
+			w := int32(s.Weight)
+			b.Weight = &w
+		}
+		ret = append(ret, b)
+	}
+	return ret
+}
@@ -10,54 +10,51 @@ import (
 	v1 "k8s.io/api/batch/v1"
 	v1beta1 "k8s.io/api/batch/v1beta1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectCronJobs(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectCronJobs(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	cjs := make([]*inventory.Workload, 0)
-	v1Jobs, v1Err := collectCronJobsV1(ctx, cs, client)
+	v1Jobs, v1Err := collectCronJobsV1(ctx, kc)
 	cjs = append(cjs, v1Jobs...)
 	var (
 		v1BetaErr  error
 		v1BetaJobs []*inventory.Workload
 	)
 	if len(cjs) == 0 {
-		v1BetaJobs, v1BetaErr = collectCronJobsV1beta1(ctx, cs, client)
+		v1BetaJobs, v1BetaErr = collectCronJobsV1beta1(ctx, kc)
 		cjs = append(cjs, v1BetaJobs...)
 	}
 	return cjs, errors.Join(v1Err, v1BetaErr)
 }
 
-func collectCronJobsV1beta1(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectCronJobsV1beta1(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	cjs := make([]*inventory.Workload, 0)
-	cronJobList, err := cs.BatchV1beta1().
-		CronJobs("").
-		List(ctx, metav1.ListOptions{})
-	if err != nil && !k8serrors.IsNotFound(err) {
+	cronJobList := &v1beta1.CronJobList{}
+	err := kc.List(ctx, cronJobList)
+	if err != nil && !k8serrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
 		return nil, fmt.Errorf("getting CronJobs/v1beta1: %v", err)
 	}
 	var errs []error
 	for _, o := range cronJobList.Items {
-		cj, err := collectCronJob(ctx, inventory.NewCronJob(), client, o)
+		cj, err := collectCronJob(ctx, inventory.NewCronJob(), kc, o)
 		errs = append(errs, err)
 		cjs = append(cjs, cj)
 	}
 	return cjs, errors.Join(errs...)
 }
 
-func collectCronJobsV1(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectCronJobsV1(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	cjs := make([]*inventory.Workload, 0)
-	cronJobList, err := cs.BatchV1().
-		CronJobs("").
-		List(ctx, metav1.ListOptions{})
-	if err != nil && !k8serrors.IsNotFound(err) {
+	cronJobList := &v1.CronJobList{}
+	err := kc.List(ctx, cronJobList)
+	if err != nil && !k8serrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
 		return nil, fmt.Errorf("getting CronJobs/v1: %v", err)
 	}
 	var errs []error
 	for _, o := range cronJobList.Items {
-		cj, err := collectCronJob(ctx, inventory.NewCronJob(), client, o)
+		cj, err := collectCronJob(ctx, inventory.NewCronJob(), kc, o)
 		errs = append(errs, err)
 		cjs = append(cjs, cj)
 	}
@@ -0,0 +1,193 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	storagev1 "k8s.io/api/storage/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func init() {
+	Register(NewCollector("csi", []string{"storage.k8s.io/v1/csidrivers"}, collectCSI))
+}
+
+type CSIDriver struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 CSIDriverSpec `json:"spec"`
+}
+
+type CSIDriverSpec struct {
+	AttachRequired       bool
+	PodInfoOnMount       bool
+	StorageCapacity      bool
+	RequiresRepublish    bool
+	SELinuxMount         bool
+	FSGroupPolicy        string
+	VolumeLifecycleModes []string
+}
+
+// CSINode lists the CSI drivers registered on a node
+type CSINode struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 CSINodeSpec `json:"spec"`
+}
+
+type CSINodeSpec struct {
+	Drivers []CSINodeDriver
+}
+
+type CSINodeDriver struct {
+	Name   string
+	NodeID string
+	// AttachLimit is the number of volumes of the driver that can be
+	// attached to the node. Not set if there is no limit.
+	AttachLimit  *int32 `json:",omitempty"`
+	TopologyKeys []string
+}
+
+type VolumeAttachment struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 VolumeAttachmentSpec   `json:"spec"`
+	Status               VolumeAttachmentStatus `json:"status"`
+}
+
+type VolumeAttachmentSpec struct {
+	Attacher             string
+	NodeName             string
+	PersistentVolumeName string `json:",omitempty"`
+}
+
+type VolumeAttachmentStatus struct {
+	Attached    bool
+	AttachError string `json:",omitempty"`
+	DetachError string `json:",omitempty"`
+}
+
+func NewCSIDriver() *CSIDriver {
+	return &CSIDriver{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "CSIDriver",
+			APIGroup:     storagev1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "csidrivers",
+		},
+	}
+}
+
+func NewCSINode() *CSINode {
+	return &CSINode{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "CSINode",
+			APIGroup:     storagev1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "csinodes",
+		},
+	}
+}
+
+func NewVolumeAttachment() *VolumeAttachment {
+	return &VolumeAttachment{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "VolumeAttachment",
+			APIGroup:     storagev1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "volumeattachments",
+		},
+	}
+}
+
+func collectCSI(ctx context.Context, cl *Clients, i *Inventory) error {
+	drivers, driversErr := collectCSIDrivers(ctx, cl.Client)
+	i.Storage.CSIDrivers = drivers
+	nodes, nodesErr := collectCSINodes(ctx, cl.Client)
+	i.Storage.CSINodes = nodes
+	attachments, attachmentsErr := collectVolumeAttachments(ctx, cl.Client)
+	i.Storage.VolumeAttachments = attachments
+	return errors.Join(driversErr, nodesErr, attachmentsErr)
+}
+
+func collectCSIDrivers(ctx context.Context, kc client.Client) ([]*CSIDriver, error) {
+	drivers := make([]*CSIDriver, 0)
+	driverList := &storagev1.CSIDriverList{}
+	if err := kc.List(ctx, driverList); err != nil {
+		return nil, fmt.Errorf("getting CSIDrivers: %v", err)
+	}
+	for _, o := range driverList.Items {
+		r := NewCSIDriver()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		// Defaults as applied by the API server
+		r.Spec = CSIDriverSpec{
+			AttachRequired:       o.Spec.AttachRequired == nil || *o.Spec.AttachRequired,
+			PodInfoOnMount:       o.Spec.PodInfoOnMount != nil && *o.Spec.PodInfoOnMount,
+			StorageCapacity:      o.Spec.StorageCapacity != nil && *o.Spec.StorageCapacity,
+			RequiresRepublish:    o.Spec.RequiresRepublish != nil && *o.Spec.RequiresRepublish,
+			SELinuxMount:         o.Spec.SELinuxMount != nil && *o.Spec.SELinuxMount,
+			FSGroupPolicy:        string(storagev1.ReadWriteOnceWithFSTypeFSGroupPolicy),
+			VolumeLifecycleModes: make([]string, 0, len(o.Spec.VolumeLifecycleModes)),
+		}
+		if o.Spec.FSGroupPolicy != nil {
+			r.Spec.FSGroupPolicy = string(*o.Spec.FSGroupPolicy)
+		}
+		for _, m := range o.Spec.VolumeLifecycleModes {
+			r.Spec.VolumeLifecycleModes = append(r.Spec.VolumeLifecycleModes, string(m))
+		}
+		if len(r.Spec.VolumeLifecycleModes) == 0 {
+			r.Spec.VolumeLifecycleModes = append(r.Spec.VolumeLifecycleModes, string(storagev1.VolumeLifecyclePersistent))
+		}
+		drivers = append(drivers, r)
+	}
+	return drivers, nil
+}
+
+func collectCSINodes(ctx context.Context, kc client.Client) ([]*CSINode, error) {
+	nodes := make([]*CSINode, 0)
+	nodeList := &storagev1.CSINodeList{}
+	if err := kc.List(ctx, nodeList); err != nil {
+		return nil, fmt.Errorf("getting CSINodes: %v", err)
+	}
+	for _, o := range nodeList.Items {
+		r := NewCSINode()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec.Drivers = make([]CSINodeDriver, 0, len(o.Spec.Drivers))
+		for _, d := range o.Spec.Drivers {
+			driver := CSINodeDriver{Name: d.Name, NodeID: d.NodeID, TopologyKeys: d.TopologyKeys}
+			if d.Allocatable != nil {
+				driver.AttachLimit = d.Allocatable.Count
+			}
+			r.Spec.Drivers = append(r.Spec.Drivers, driver)
+		}
+		nodes = append(nodes, r)
+	}
+	return nodes, nil
+}
+
+func collectVolumeAttachments(ctx context.Context, kc client.Client) ([]*VolumeAttachment, error) {
+	attachments := make([]*VolumeAttachment, 0)
+	attachmentList := &storagev1.VolumeAttachmentList{}
+	if err := kc.List(ctx, attachmentList); err != nil {
+		return nil, fmt.Errorf("getting VolumeAttachments: %v", err)
+	}
+	for _, o := range attachmentList.Items {
+		r := NewVolumeAttachment()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec = VolumeAttachmentSpec{Attacher: o.Spec.Attacher, NodeName: o.Spec.NodeName}
+		if o.Spec.Source.PersistentVolumeName != nil {
+			r.Spec.PersistentVolumeName = *o.Spec.Source.PersistentVolumeName
+		}
+		r.Status.Attached = o.Status.Attached
+		if o.Status.AttachError != nil {
+			r.Status.AttachError = o.Status.AttachError.Message
+		}
+		if o.Status.DetachError != nil {
+			r.Status.DetachError = o.Status.DetachError.Message
+		}
+		attachments = append(attachments, r)
+	}
+	return attachments, nil
+}
@@ -7,23 +7,19 @@ import (
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/apps/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectDaemonSets(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectDaemonSets(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	dsets := make([]*inventory.Workload, 0)
 
-	daemonSetList, err := cs.AppsV1().
-		DaemonSets("").
-		List(ctx, metav1.ListOptions{})
-	if err != nil {
+	daemonSetList := &v1.DaemonSetList{}
+	if err := kc.List(ctx, daemonSetList); err != nil {
 		return nil, fmt.Errorf("getting DaemonSets: %v", err)
 	}
 	var errs []error
 	for _, o := range daemonSetList.Items {
-		dset, err := collectDaemonSet(ctx, client, o)
+		dset, err := collectDaemonSet(ctx, kc, o)
 		errs = append(errs, err)
 		dsets = append(dsets, dset)
 	}
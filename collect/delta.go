@@ -0,0 +1,181 @@
+package collect
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// inventoryState identifies the objects of an uploaded inventory by key and
+// content hash. It is what deltas are computed against.
+type inventoryState struct {
+	// Hash of the complete inventory, or the ETag returned by the server
+	Hash string
+	// Hash of everything in the inventory but the objects
+	Rest    string
+	Objects map[string]string
+	Created time.Time
+}
+
+// deltaObject is an object added, modified or removed since the last accepted
+// inventory. Path is the JSON pointer of the list holding the object.
+type deltaObject struct {
+	Path      string          `json:"path"`
+	Kind      string          `json:"kind,omitempty"`
+	Namespace string          `json:"namespace,omitempty"`
+	Name      string          `json:"name"`
+	Object    json.RawMessage `json:"object,omitempty"`
+}
+
+// inventoryDelta is uploaded instead of the full inventory when delta uploads
+// are enabled. Inventory holds everything but the objects and is only set when
+// it has changed.
+type inventoryDelta struct {
+	Base      string          `json:"base"`
+	Hash      string          `json:"hash"`
+	Inventory json.RawMessage `json:"inventory,omitempty"`
+	Added     []deltaObject   `json:"added,omitempty"`
+	Modified  []deltaObject   `json:"modified,omitempty"`
+	Removed   []deltaObject   `json:"removed,omitempty"`
+}
+
+// flattenedInventory is an inventory split into its objects and the rest
+type flattenedInventory struct {
+	state   *inventoryState
+	rest    json.RawMessage
+	objects map[string]deltaObject
+}
+
+// flattenInventory moves every list of objects with metadata out of the JSON
+// encoded inventory and keys them by path, kind, namespace and name
+func flattenInventory(data []byte) (*flattenedInventory, error) {
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding inventory: %v", err)
+	}
+
+	f := &flattenedInventory{
+		state: &inventoryState{
+			Hash:    hash(data),
+			Objects: make(map[string]string),
+			Created: time.Now(),
+		},
+		objects: make(map[string]deltaObject),
+	}
+	var err error
+	if f.rest, err = json.Marshal(f.extract("", doc)); err != nil {
+		return nil, err
+	}
+	f.state.Rest = hash(f.rest)
+	return f, nil
+}
+
+func (f *flattenedInventory) extract(path string, v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = f.extract(path+"/"+pointerEscaper.Replace(k), e)
+		}
+		return t
+	case []interface{}:
+		objs, ok := objectsOf(path, t)
+		if !ok {
+			return t
+		}
+		for k, o := range objs {
+			f.objects[k] = o
+			f.state.Objects[k] = hash(o.Object)
+		}
+		return []interface{}{}
+	}
+	return v
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// objectsOf returns the elements of l keyed by path, kind, namespace and name
+// if all of them have a name and no two of them have the same key
+func objectsOf(path string, l []interface{}) (map[string]deltaObject, bool) {
+	if len(l) == 0 {
+		return nil, false
+	}
+	objs := make(map[string]deltaObject, len(l))
+	for _, e := range l {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		meta, _ := m["metadata"].(map[string]interface{})
+		name, _ := meta["name"].(string)
+		if name == "" {
+			return nil, false
+		}
+		namespace, _ := meta["namespace"].(string)
+		kind, _ := m["kind"].(string)
+		key := strings.Join([]string{path, kind, namespace, name}, "|")
+		if _, found := objs[key]; found {
+			return nil, false
+		}
+		raw, err := json.Marshal(m)
+		if err != nil {
+			return nil, false
+		}
+		objs[key] = deltaObject{Path: path, Kind: kind, Namespace: namespace, Name: name, Object: raw}
+	}
+	return objs, true
+}
+
+// deltaFrom returns the changes from base to f
+func (f *flattenedInventory) deltaFrom(base *inventoryState) *inventoryDelta {
+	d := &inventoryDelta{Base: base.Hash, Hash: f.state.Hash}
+	if f.state.Rest != base.Rest {
+		d.Inventory = f.rest
+	}
+	for k, h := range f.state.Objects {
+		baseHash, found := base.Objects[k]
+		switch {
+		case !found:
+			d.Added = append(d.Added, f.objects[k])
+		case baseHash != h:
+			d.Modified = append(d.Modified, f.objects[k])
+		}
+	}
+	for k := range base.Objects {
+		if _, found := f.state.Objects[k]; !found {
+			p := strings.SplitN(k, "|", 4)
+			d.Removed = append(d.Removed, deltaObject{Path: p[0], Kind: p[1], Namespace: p[2], Name: p[3]})
+		}
+	}
+	for _, l := range [][]deltaObject{d.Added, d.Modified, d.Removed} {
+		sort.Slice(l, func(i, j int) bool {
+			return strings.Join([]string{l[i].Path, l[i].Kind, l[i].Namespace, l[i].Name}, "|") <
+				strings.Join([]string{l[j].Path, l[j].Kind, l[j].Namespace, l[j].Name}, "|")
+		})
+	}
+	return d
+}
+
+func (d *inventoryDelta) empty() bool {
+	return d.Inventory == nil && len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Removed) == 0
+}
+
+// entityTag quotes a hash for use in an If-Match header. ETags returned by the
+// server are used as is.
+func entityTag(h string) string {
+	if strings.HasPrefix(h, `"`) || strings.HasPrefix(h, "W/") {
+		return h
+	}
+	return `"` + h + `"`
+}
+
+func hash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
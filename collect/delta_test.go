@@ -0,0 +1,162 @@
+package collect
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestFlattenInventory(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		keys []string
+		rest string
+	}{
+		{
+			name: "objects are moved out",
+			doc:  `{"Workloads":[{"kind":"Deployment","metadata":{"name":"web","namespace":"default"}},{"kind":"Pod","metadata":{"name":"web-1","namespace":"default"}}],"ClientVersion":"1.0"}`,
+			keys: []string{"/Workloads|Deployment|default|web", "/Workloads|Pod|default|web-1"},
+			rest: `{"ClientVersion":"1.0","Workloads":[]}`,
+		},
+		{
+			name: "nested lists are keyed by pointer",
+			doc:  `{"Storage":{"PersistentVolumes":[{"kind":"PersistentVolume","metadata":{"name":"pv-1"}}]}}`,
+			keys: []string{"/Storage/PersistentVolumes|PersistentVolume||pv-1"},
+			rest: `{"Storage":{"PersistentVolumes":[]}}`,
+		},
+		{
+			name: "keys are escaped",
+			doc:  `{"a/b":[{"metadata":{"name":"x"}}]}`,
+			keys: []string{"/a~1b|||x"},
+			rest: `{"a/b":[]}`,
+		},
+		{
+			name: "lists without names are kept",
+			doc:  `{"CollectionErrors":["failed"],"Nodes":[{"metadata":{}}]}`,
+			rest: `{"CollectionErrors":["failed"],"Nodes":[{"metadata":{}}]}`,
+		},
+		{
+			name: "lists with duplicate keys are kept",
+			doc:  `{"Nodes":[{"metadata":{"name":"a"}},{"metadata":{"name":"a"}}]}`,
+			rest: `{"Nodes":[{"metadata":{"name":"a"}},{"metadata":{"name":"a"}}]}`,
+		},
+		{
+			name: "empty lists are kept",
+			doc:  `{"Nodes":[]}`,
+			rest: `{"Nodes":[]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := flattenInventory([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := make([]string, 0, len(f.objects))
+			for k := range f.objects {
+				keys = append(keys, k)
+				if f.state.Objects[k] != hash(f.objects[k].Object) {
+					t.Errorf("%s: hash does not match the object", k)
+				}
+			}
+			sort.Strings(keys)
+			if len(keys) != len(tt.keys) || (len(keys) > 0 && !reflect.DeepEqual(keys, tt.keys)) {
+				t.Errorf("keys: got %v, want %v", keys, tt.keys)
+			}
+			if string(f.rest) != tt.rest {
+				t.Errorf("rest: got %s, want %s", f.rest, tt.rest)
+			}
+			if f.state.Hash != hash([]byte(tt.doc)) {
+				t.Errorf("hash of the inventory not set")
+			}
+		})
+	}
+}
+
+func TestFlattenInventoryInvalid(t *testing.T) {
+	if _, err := flattenInventory([]byte(`{"Nodes":`)); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestDeltaFrom(t *testing.T) {
+	base := `{"ClientVersion":"1.0","Workloads":[{"kind":"Pod","metadata":{"name":"a","namespace":"x"}},{"kind":"Pod","metadata":{"name":"b","namespace":"x"},"spec":1}]}`
+	tests := []struct {
+		name      string
+		doc       string
+		inventory bool
+		added     []string
+		modified  []string
+		removed   []string
+	}{
+		{
+			name: "unchanged",
+			doc:  base,
+		},
+		{
+			name:     "object modified",
+			doc:      `{"ClientVersion":"1.0","Workloads":[{"kind":"Pod","metadata":{"name":"a","namespace":"x"}},{"kind":"Pod","metadata":{"name":"b","namespace":"x"},"spec":2}]}`,
+			modified: []string{"b"},
+		},
+		{
+			name:    "object added and removed",
+			doc:     `{"ClientVersion":"1.0","Workloads":[{"kind":"Pod","metadata":{"name":"c","namespace":"x"}},{"kind":"Pod","metadata":{"name":"b","namespace":"x"},"spec":1}]}`,
+			added:   []string{"c"},
+			removed: []string{"a"},
+		},
+		{
+			name:      "rest changed",
+			doc:       `{"ClientVersion":"1.1","Workloads":[{"kind":"Pod","metadata":{"name":"a","namespace":"x"}},{"kind":"Pod","metadata":{"name":"b","namespace":"x"},"spec":1}]}`,
+			inventory: true,
+		},
+		{
+			name:    "all objects removed",
+			doc:     `{"ClientVersion":"1.0","Workloads":[]}`,
+			removed: []string{"a", "b"},
+		},
+	}
+	names := func(l []deltaObject) []string {
+		var ret []string
+		for _, o := range l {
+			ret = append(ret, o.Name)
+		}
+		return ret
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, err := flattenInventory([]byte(base))
+			if err != nil {
+				t.Fatal(err)
+			}
+			to, err := flattenInventory([]byte(tt.doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := to.deltaFrom(from.state)
+			if d.Base != from.state.Hash || d.Hash != to.state.Hash {
+				t.Errorf("hashes: got %s %s", d.Base, d.Hash)
+			}
+			if (d.Inventory != nil) != tt.inventory {
+				t.Errorf("inventory: got %s", d.Inventory)
+			}
+			if got := names(d.Added); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("added: got %v, want %v", got, tt.added)
+			}
+			if got := names(d.Modified); !reflect.DeepEqual(got, tt.modified) {
+				t.Errorf("modified: got %v, want %v", got, tt.modified)
+			}
+			if got := names(d.Removed); !reflect.DeepEqual(got, tt.removed) {
+				t.Errorf("removed: got %v, want %v", got, tt.removed)
+			}
+			for _, o := range d.Removed {
+				if o.Path != "/Workloads" || o.Kind != "Pod" || o.Namespace != "x" || o.Object != nil {
+					t.Errorf("removed object: got %+v", o)
+				}
+			}
+			if d.empty() != (tt.name == "unchanged") {
+				t.Errorf("empty: got %v", d.empty())
+			}
+		})
+	}
+}
@@ -8,22 +8,19 @@ import (
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/apps/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectDeployments(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectDeployments(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	deployments := make([]*inventory.Workload, 0)
-	deploymentList, err := cs.AppsV1().
-		Deployments("").
-		List(ctx, metav1.ListOptions{})
+	deploymentList := &v1.DeploymentList{}
+	err := kc.List(ctx, deploymentList)
 	if err != nil && !k8serrors.IsNotFound(err) {
 		return nil, fmt.Errorf("getting Deployments: %v", err)
 	}
 	var errs []error
 	for _, o := range deploymentList.Items {
-		deployment, err := collectDeployment(ctx, client, o)
+		deployment, err := collectDeployment(ctx, kc, o)
 		errs = append(errs, err)
 		deployments = append(deployments, deployment)
 	}
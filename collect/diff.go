@@ -0,0 +1,265 @@
+package collect
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type snapshotRef struct {
+	Generation uint64    `json:"generation"`
+	Created    time.Time `json:"created"`
+}
+
+type objectRef struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+type fieldChange struct {
+	Path string `json:"path"`
+	From any    `json:"from"`
+	To   any    `json:"to"`
+}
+
+type changedObject struct {
+	objectRef
+	Changes []fieldChange `json:"changes"`
+}
+
+type sectionDiff struct {
+	Added   []objectRef     `json:"added,omitempty"`
+	Removed []objectRef     `json:"removed,omitempty"`
+	Changed []changedObject `json:"changed,omitempty"`
+}
+
+type imageDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// inventoryDiff lists the objects added, removed and changed between two
+// snapshots, by section of the query API
+type inventoryDiff struct {
+	From     snapshotRef             `json:"from"`
+	To       snapshotRef             `json:"to"`
+	Sections map[string]*sectionDiff `json:"sections"`
+	Images   *imageDiff              `json:"images,omitempty"`
+}
+
+func diffSnapshots(from, to *snapshot) (*inventoryDiff, error) {
+	d := &inventoryDiff{
+		From:     snapshotRef{Generation: from.Generation, Created: from.Created},
+		To:       snapshotRef{Generation: to.Generation, Created: to.Created},
+		Sections: make(map[string]*sectionDiff),
+	}
+	for name := range apiSections {
+		a, err := from.section(name)
+		if err != nil {
+			return nil, err
+		}
+		b, err := to.section(name)
+		if err != nil {
+			return nil, err
+		}
+		if sd := diffSection(a, b); sd != nil {
+			d.Sections[name] = sd
+		}
+		if name == "workloads" {
+			d.Images = diffImages(a, b)
+		}
+	}
+	return d, nil
+}
+
+// diffSection compares two sections sorted by key
+func diffSection(a, b []apiObject) *sectionDiff {
+	sd := &sectionDiff{}
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || (len(a) > 0 && a[0].key < b[0].key):
+			sd.Removed = append(sd.Removed, refOf(a[0]))
+			a = a[1:]
+		case len(a) == 0 || b[0].key < a[0].key:
+			sd.Added = append(sd.Added, refOf(b[0]))
+			b = b[1:]
+		default:
+			var changes []fieldChange
+			diffValues("", a[0].object, b[0].object, &changes)
+			if len(changes) > 0 {
+				sd.Changed = append(sd.Changed, changedObject{objectRef: refOf(b[0]), Changes: changes})
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	if sd.Added == nil && sd.Removed == nil && sd.Changed == nil {
+		return nil
+	}
+	return sd
+}
+
+func refOf(o apiObject) objectRef {
+	meta, _ := o.object["metadata"].(map[string]any)
+	name, _ := meta["name"].(string)
+	return objectRef{Kind: o.kind, Namespace: o.namespace, Name: name}
+}
+
+// diffValues appends the paths of the leaves that differ between a and b
+func diffValues(path string, a, b any, changes *[]fieldChange) {
+	switch at := a.(type) {
+	case map[string]any:
+		bt, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool)
+		for k := range at {
+			keys[k] = true
+		}
+		for k := range bt {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffValues(p, at[k], bt[k], changes)
+		}
+		return
+	case []any:
+		bt, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(at), len(bt)); i++ {
+			var av, bv any
+			if i < len(at) {
+				av = at[i]
+			}
+			if i < len(bt) {
+				bv = bt[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), av, bv, changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, fieldChange{Path: path, From: a, To: b})
+	}
+}
+
+// diffImages compares the container images used by two sets of workloads
+func diffImages(a, b []apiObject) *imageDiff {
+	ai, bi := images(a), images(b)
+	d := &imageDiff{}
+	for img := range bi {
+		if !ai[img] {
+			d.Added = append(d.Added, img)
+		}
+	}
+	for img := range ai {
+		if !bi[img] {
+			d.Removed = append(d.Removed, img)
+		}
+	}
+	if d.Added == nil && d.Removed == nil {
+		return nil
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	return d
+}
+
+func images(objs []apiObject) map[string]bool {
+	ret := make(map[string]bool)
+	var walk func(v any)
+	walk = func(v any) {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, e := range t {
+				if s, ok := e.(string); ok && strings.EqualFold(k, "image") && s != "" {
+					ret[s] = true
+					continue
+				}
+				walk(e)
+			}
+		case []any:
+			for _, e := range t {
+				walk(e)
+			}
+		}
+	}
+	for _, o := range objs {
+		walk(o.object)
+	}
+	return ret
+}
+
+// serveDiff compares the snapshots given by from and to, which default to
+// the current snapshot
+func (c *InventoryCollection) serveDiff(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if c.history == nil {
+		apiError(w, http.StatusNotFound, "history is not enabled")
+		return
+	}
+	current := c.snapshot()
+	if current == nil {
+		http.Error(w, errHTTPNoInventory.JSON(), http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("from") == "" {
+		apiError(w, http.StatusBadRequest, "from is required")
+		return
+	}
+	from, err := c.history.find(q.Get("from"), current)
+	if err != nil {
+		historyError(w, "from", err)
+		return
+	}
+	to := current
+	if ref := q.Get("to"); ref != "" {
+		if to, err = c.history.find(ref, current); err != nil {
+			historyError(w, "to", err)
+			return
+		}
+	}
+
+	d, err := diffSnapshots(from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("comparing snapshots")
+		http.Error(w, errHTTPInternalError.JSON(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, d)
+}
+
+func (c *InventoryCollection) serveHistory(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if c.history == nil {
+		apiError(w, http.StatusNotFound, "history is not enabled")
+		return
+	}
+	writeJSON(w, map[string]any{"items": c.history.list()})
+}
+
+func historyError(w http.ResponseWriter, param string, err error) {
+	if err == errHistoryNotFound {
+		apiError(w, http.StatusNotFound, fmt.Sprintf("%s: %v", param, err))
+		return
+	}
+	apiError(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", param, err))
+}
@@ -0,0 +1,171 @@
+package collect
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// testObjects converts a JSON list to objects of the query API
+func testObjects(t *testing.T, doc string) []apiObject {
+	t.Helper()
+	var l []map[string]any
+	if err := json.Unmarshal([]byte(doc), &l); err != nil {
+		t.Fatal(err)
+	}
+	objs, err := toAPIObjects(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objs
+}
+
+func TestDiffValues(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []fieldChange
+	}{
+		{
+			name: "equal",
+			a:    `{"a":1,"b":{"c":[1,2]}}`,
+			b:    `{"a":1,"b":{"c":[1,2]}}`,
+		},
+		{
+			name: "leaf changed",
+			a:    `{"a":1,"b":{"c":"x"}}`,
+			b:    `{"a":1,"b":{"c":"y"}}`,
+			want: []fieldChange{{Path: "b.c", From: "x", To: "y"}},
+		},
+		{
+			name: "keys added and removed in order",
+			a:    `{"b":1,"c":2}`,
+			b:    `{"a":3,"c":2}`,
+			want: []fieldChange{{Path: "a", To: 3.0}, {Path: "b", From: 1.0}},
+		},
+		{
+			name: "list grown",
+			a:    `{"l":["x"]}`,
+			b:    `{"l":["x","y"]}`,
+			want: []fieldChange{{Path: "l[1]", To: "y"}},
+		},
+		{
+			name: "list element changed",
+			a:    `{"l":[{"n":1},{"n":2}]}`,
+			b:    `{"l":[{"n":1},{"n":3}]}`,
+			want: []fieldChange{{Path: "l[1].n", From: 2.0, To: 3.0}},
+		},
+		{
+			name: "type changed",
+			a:    `{"a":{"b":1}}`,
+			b:    `{"a":[1]}`,
+			want: []fieldChange{{Path: "a", From: map[string]any{"b": 1.0}, To: []any{1.0}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b any
+			if err := json.Unmarshal([]byte(tt.a), &a); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.b), &b); err != nil {
+				t.Fatal(err)
+			}
+			var got []fieldChange
+			diffValues("", a, b, &got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffSection(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want *sectionDiff
+	}{
+		{
+			name: "unchanged",
+			a:    `[{"kind":"Pod","metadata":{"name":"a","namespace":"x"}}]`,
+			b:    `[{"kind":"Pod","metadata":{"name":"a","namespace":"x"}}]`,
+		},
+		{
+			name: "empty",
+			a:    `[]`,
+			b:    `[]`,
+		},
+		{
+			name: "added, removed and changed",
+			a:    `[{"kind":"Pod","metadata":{"name":"a","namespace":"x"}},{"kind":"Pod","metadata":{"name":"b","namespace":"x"},"spec":{"n":1}}]`,
+			b:    `[{"kind":"Pod","metadata":{"name":"c","namespace":"x"}},{"kind":"Pod","metadata":{"name":"b","namespace":"x"},"spec":{"n":2}}]`,
+			want: &sectionDiff{
+				Added:   []objectRef{{Kind: "Pod", Namespace: "x", Name: "c"}},
+				Removed: []objectRef{{Kind: "Pod", Namespace: "x", Name: "a"}},
+				Changed: []changedObject{{
+					objectRef: objectRef{Kind: "Pod", Namespace: "x", Name: "b"},
+					Changes:   []fieldChange{{Path: "spec.n", From: 1.0, To: 2.0}},
+				}},
+			},
+		},
+		{
+			name: "same name in other namespace",
+			a:    `[{"kind":"Pod","metadata":{"name":"a","namespace":"x"}}]`,
+			b:    `[{"kind":"Pod","metadata":{"name":"a","namespace":"y"}}]`,
+			want: &sectionDiff{
+				Added:   []objectRef{{Kind: "Pod", Namespace: "y", Name: "a"}},
+				Removed: []objectRef{{Kind: "Pod", Namespace: "x", Name: "a"}},
+			},
+		},
+		{
+			name: "all removed",
+			a:    `[{"kind":"Node","metadata":{"name":"a"}},{"kind":"Node","metadata":{"name":"b"}}]`,
+			b:    `[]`,
+			want: &sectionDiff{
+				Removed: []objectRef{{Kind: "Node", Name: "a"}, {Kind: "Node", Name: "b"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffSection(testObjects(t, tt.a), testObjects(t, tt.b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffImages(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want *imageDiff
+	}{
+		{
+			name: "unchanged",
+			a:    `[{"kind":"Pod","metadata":{"name":"a"},"spec":{"Containers":[{"Image":"nginx:1"}]}}]`,
+			b:    `[{"kind":"Pod","metadata":{"name":"b"},"spec":{"Containers":[{"Image":"nginx:1"}]}}]`,
+		},
+		{
+			name: "image updated",
+			a:    `[{"kind":"Pod","metadata":{"name":"a"},"spec":{"Containers":[{"Image":"nginx:1"},{"Image":"envoy:1"}]}}]`,
+			b:    `[{"kind":"Pod","metadata":{"name":"a"},"spec":{"Containers":[{"Image":"nginx:2"},{"Image":"envoy:1"}]}}]`,
+			want: &imageDiff{Added: []string{"nginx:2"}, Removed: []string{"nginx:1"}},
+		},
+		{
+			name: "empty images are ignored",
+			a:    `[{"kind":"Pod","metadata":{"name":"a"},"spec":{"Containers":[{"Image":""}]}}]`,
+			b:    `[]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffImages(testObjects(t, tt.a), testObjects(t, tt.b))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package collect
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
@@ -19,4 +20,31 @@ func (e errHTTP) JSON() string {
 	return string(b)
 }
 
+// CollectorError is recorded in CollectorErrors when a collector fails
+type CollectorError struct {
+	Collector string `json:"collector"`
+	Timeout   bool   `json:"timeout,omitempty"`
+	Duration  string `json:"duration"`
+	Message   string `json:"error"`
+	err       error
+}
+
+func (e CollectorError) Error() string {
+	return fmt.Sprintf("collector %s: %s", e.Collector, e.Message)
+}
+
+func (e CollectorError) Unwrap() error {
+	return e.err
+}
+
+// errUpload is returned when the inventory server does not accept an upload
+type errUpload struct {
+	StatusCode int
+}
+
+func (e *errUpload) Error() string {
+	return fmt.Sprintf("upload failed with status %d", e.StatusCode)
+}
+
 var errHTTPInternalError = &errHTTP{http.StatusInternalServerError, "internal server error"}
+var errHTTPNoInventory = &errHTTP{http.StatusServiceUnavailable, "no inventory collected yet"}
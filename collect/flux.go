@@ -0,0 +1,406 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ck "k8s.io/client-go/kubernetes"
+)
+
+func init() {
+	Register(WithLink(NewCollector("flux", nil, collectFlux), linkFlux))
+}
+
+const (
+	fluxSourceGroup    = "source.toolkit.fluxcd.io"
+	fluxKustomizeGroup = "kustomize.toolkit.fluxcd.io"
+	fluxHelmGroup      = "helm.toolkit.fluxcd.io"
+)
+
+type Flux struct {
+	GitRepositories  []*FluxSource
+	OCIRepositories  []*FluxSource
+	HelmRepositories []*FluxSource
+	Kustomizations   []*Kustomization
+	HelmReleases     []*HelmRelease
+}
+
+// FluxSource is a Flux GitRepository, OCIRepository or HelmRepository
+type FluxSource struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 FluxSourceSpec   `json:"spec"`
+	Status               FluxSourceStatus `json:"status"`
+}
+
+type FluxSourceSpec struct {
+	URL      string
+	Interval string
+	// Ref is the reference checked out of Git and OCI repositories
+	Ref *FluxSourceRef `json:",omitempty"`
+	// Type of a HelmRepository, default or oci
+	Type    string `json:",omitempty"`
+	Suspend bool
+}
+
+type FluxSourceRef struct {
+	Branch string `json:",omitempty"`
+	Tag    string `json:",omitempty"`
+	SemVer string `json:",omitempty"`
+	Commit string `json:",omitempty"`
+	Name   string `json:",omitempty"`
+	Digest string `json:",omitempty"`
+}
+
+type FluxSourceStatus struct {
+	// Revision of the last fetched artifact
+	Revision   string `json:",omitempty"`
+	Ready      bool
+	Conditions []Condition
+}
+
+type Kustomization struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 KustomizationSpec `json:"spec"`
+	Status               FluxApplyStatus   `json:"status"`
+	// Workloads labelled as applied by the Kustomization
+	Workloads []*inventory.RootOwner `json:",omitempty"`
+}
+
+type KustomizationSpec struct {
+	SourceRef          Reference
+	Path               string `json:",omitempty"`
+	Interval           string
+	Prune              bool
+	Suspend            bool
+	TargetNamespace    string `json:",omitempty"`
+	ServiceAccountName string `json:",omitempty"`
+}
+
+type HelmRelease struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 HelmReleaseSpec `json:"spec"`
+	Status               FluxApplyStatus `json:"status"`
+	// Workloads labelled as installed by the HelmRelease
+	Workloads []*inventory.RootOwner `json:",omitempty"`
+}
+
+type HelmReleaseSpec struct {
+	// Chart and Version are not set for releases of a chart referenced by
+	// chartRef
+	Chart   string `json:",omitempty"`
+	Version string `json:",omitempty"`
+	// SourceRef is the source of the chart or the chartRef
+	SourceRef       Reference
+	ReleaseName     string `json:",omitempty"`
+	Interval        string
+	Suspend         bool
+	TargetNamespace string `json:",omitempty"`
+}
+
+// FluxApplyStatus is the status of a Kustomization or HelmRelease
+type FluxApplyStatus struct {
+	LastAppliedRevision   string `json:",omitempty"`
+	LastAttemptedRevision string `json:",omitempty"`
+	Ready                 bool
+	Conditions            []Condition
+}
+
+// fluxSourceRef holds the sourceRef and chartRef of Kustomizations and
+// HelmReleases
+type fluxSourceRef struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// fluxSource holds the fields read from GitRepositories, OCIRepositories and
+// HelmRepositories of source.toolkit.fluxcd.io v1 and v1beta2
+type fluxSource struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		URL      string `json:"url"`
+		Interval string `json:"interval"`
+		Ref      *struct {
+			Branch string `json:"branch"`
+			Tag    string `json:"tag"`
+			SemVer string `json:"semver"`
+			Commit string `json:"commit"`
+			Name   string `json:"name"`
+			Digest string `json:"digest"`
+		} `json:"ref"`
+		Type    string `json:"type"`
+		Suspend bool   `json:"suspend"`
+	} `json:"spec"`
+	Status struct {
+		Artifact *struct {
+			Revision string `json:"revision"`
+		} `json:"artifact"`
+		Conditions []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+// kustomization holds the fields read from kustomize.toolkit.fluxcd.io v1 and
+// v1beta2 Kustomizations
+type kustomization struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		SourceRef          fluxSourceRef `json:"sourceRef"`
+		Path               string        `json:"path"`
+		Interval           string        `json:"interval"`
+		Prune              bool          `json:"prune"`
+		Suspend            bool          `json:"suspend"`
+		TargetNamespace    string        `json:"targetNamespace"`
+		ServiceAccountName string        `json:"serviceAccountName"`
+	} `json:"spec"`
+	Status struct {
+		LastAppliedRevision   string             `json:"lastAppliedRevision"`
+		LastAttemptedRevision string             `json:"lastAttemptedRevision"`
+		Conditions            []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+// helmRelease holds the fields read from helm.toolkit.fluxcd.io v2, v2beta2
+// and v2beta1 HelmReleases
+type helmRelease struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		Chart *struct {
+			Spec struct {
+				Chart     string        `json:"chart"`
+				Version   string        `json:"version"`
+				SourceRef fluxSourceRef `json:"sourceRef"`
+			} `json:"spec"`
+		} `json:"chart"`
+		ChartRef        *fluxSourceRef `json:"chartRef"`
+		ReleaseName     string         `json:"releaseName"`
+		Interval        string         `json:"interval"`
+		Suspend         bool           `json:"suspend"`
+		TargetNamespace string         `json:"targetNamespace"`
+	} `json:"spec"`
+	Status struct {
+		LastAppliedRevision   string `json:"lastAppliedRevision"`
+		LastAttemptedRevision string `json:"lastAttemptedRevision"`
+		History               []struct {
+			ChartVersion string `json:"chartVersion"`
+			Status       string `json:"status"`
+		} `json:"history"`
+		Conditions []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+func NewFluxSource(kind, apiVersion, resourceType string) *FluxSource {
+	return &FluxSource{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     fluxSourceGroup,
+			APIVersion:   apiVersion,
+			ResourceType: resourceType,
+		},
+	}
+}
+
+func NewKustomization(apiVersion string) *Kustomization {
+	return &Kustomization{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "Kustomization",
+			APIGroup:     fluxKustomizeGroup,
+			APIVersion:   apiVersion,
+			ResourceType: "kustomizations",
+		},
+	}
+}
+
+func NewHelmRelease(apiVersion string) *HelmRelease {
+	return &HelmRelease{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "HelmRelease",
+			APIGroup:     fluxHelmGroup,
+			APIVersion:   apiVersion,
+			ResourceType: "helmreleases",
+		},
+	}
+}
+
+// collectFlux reads each kind of Flux object from the newest version served.
+// Nothing is read from clusters without Flux.
+func collectFlux(ctx context.Context, cl *Clients, i *Inventory) error {
+	var errs []error
+	f := &i.CustomResources.Flux
+	for _, s := range []struct {
+		kind, resourceType string
+		sources            *[]*FluxSource
+	}{
+		{"GitRepository", "gitrepositories", &f.GitRepositories},
+		{"OCIRepository", "ocirepositories", &f.OCIRepositories},
+		{"HelmRepository", "helmrepositories", &f.HelmRepositories},
+	} {
+		var err error
+		version := servedVersion(cl.APIResources, fluxSourceGroup, s.resourceType, "v1", "v1beta2")
+		*s.sources, err = collectFluxSources(ctx, cl.Clientset, s.kind, version, s.resourceType)
+		errs = append(errs, err)
+	}
+
+	kustomizations, err := collectKustomizations(ctx, cl.Clientset, servedVersion(cl.APIResources, fluxKustomizeGroup, "kustomizations", "v1", "v1beta2"))
+	f.Kustomizations = kustomizations
+	errs = append(errs, err)
+	releases, err := collectHelmReleases(ctx, cl.Clientset, servedVersion(cl.APIResources, fluxHelmGroup, "helmreleases", "v2", "v2beta2", "v2beta1"))
+	f.HelmReleases = releases
+	errs = append(errs, err)
+	return errors.Join(errs...)
+}
+
+// servedVersion returns the first of versions in which resources serves
+// resource of group, or an empty string if none of them are served
+func servedVersion(resources map[string]bool, group, resource string, versions ...string) string {
+	for _, v := range versions {
+		if resources[group+"/"+v+"/"+resource] {
+			return v
+		}
+	}
+	return ""
+}
+
+func collectFluxSources(ctx context.Context, cs *ck.Clientset, kind, version, resourceType string) ([]*FluxSource, error) {
+	sources := make([]*FluxSource, 0)
+	if version == "" {
+		return sources, nil
+	}
+	objs, _, err := listCustomObjects[fluxSource](ctx, cs, fmt.Sprintf("/apis/%s/%s/%s", fluxSourceGroup, version, resourceType))
+	for _, o := range objs {
+		r := NewFluxSource(kind, version, resourceType)
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec = FluxSourceSpec{
+			URL:      o.Spec.URL,
+			Interval: o.Spec.Interval,
+			Type:     o.Spec.Type,
+			Suspend:  o.Spec.Suspend,
+		}
+		if ref := o.Spec.Ref; ref != nil {
+			r.Spec.Ref = &FluxSourceRef{Branch: ref.Branch, Tag: ref.Tag, SemVer: ref.SemVer, Commit: ref.Commit, Name: ref.Name, Digest: ref.Digest}
+		}
+		if o.Status.Artifact != nil {
+			r.Status.Revision = o.Status.Artifact.Revision
+		}
+		r.Status.Ready = meta.IsStatusConditionTrue(o.Status.Conditions, "Ready")
+		r.Status.Conditions = conditions(o.Status.Conditions)
+		sources = append(sources, r)
+	}
+	return sources, err
+}
+
+func collectKustomizations(ctx context.Context, cs *ck.Clientset, version string) ([]*Kustomization, error) {
+	kustomizations := make([]*Kustomization, 0)
+	if version == "" {
+		return kustomizations, nil
+	}
+	objs, _, err := listCustomObjects[kustomization](ctx, cs, fmt.Sprintf("/apis/%s/%s/kustomizations", fluxKustomizeGroup, version))
+	for _, o := range objs {
+		r := NewKustomization(version)
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec = KustomizationSpec{
+			SourceRef:          fluxReference(o.Spec.SourceRef, o.Namespace),
+			Path:               o.Spec.Path,
+			Interval:           o.Spec.Interval,
+			Prune:              o.Spec.Prune,
+			Suspend:            o.Spec.Suspend,
+			TargetNamespace:    o.Spec.TargetNamespace,
+			ServiceAccountName: o.Spec.ServiceAccountName,
+		}
+		r.Status = FluxApplyStatus{
+			LastAppliedRevision:   o.Status.LastAppliedRevision,
+			LastAttemptedRevision: o.Status.LastAttemptedRevision,
+			Ready:                 meta.IsStatusConditionTrue(o.Status.Conditions, "Ready"),
+			Conditions:            conditions(o.Status.Conditions),
+		}
+		kustomizations = append(kustomizations, r)
+	}
+	return kustomizations, err
+}
+
+func collectHelmReleases(ctx context.Context, cs *ck.Clientset, version string) ([]*HelmRelease, error) {
+	releases := make([]*HelmRelease, 0)
+	if version == "" {
+		return releases, nil
+	}
+	objs, _, err := listCustomObjects[helmRelease](ctx, cs, fmt.Sprintf("/apis/%s/%s/helmreleases", fluxHelmGroup, version))
+	for _, o := range objs {
+		r := NewHelmRelease(version)
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec = HelmReleaseSpec{
+			ReleaseName:     o.Spec.ReleaseName,
+			Interval:        o.Spec.Interval,
+			Suspend:         o.Spec.Suspend,
+			TargetNamespace: o.Spec.TargetNamespace,
+		}
+		switch {
+		case o.Spec.ChartRef != nil:
+			r.Spec.SourceRef = fluxReference(*o.Spec.ChartRef, o.Namespace)
+		case o.Spec.Chart != nil:
+			r.Spec.Chart = o.Spec.Chart.Spec.Chart
+			r.Spec.Version = o.Spec.Chart.Spec.Version
+			r.Spec.SourceRef = fluxReference(o.Spec.Chart.Spec.SourceRef, o.Namespace)
+		}
+		r.Status = FluxApplyStatus{
+			LastAppliedRevision:   o.Status.LastAppliedRevision,
+			LastAttemptedRevision: o.Status.LastAttemptedRevision,
+			Ready:                 meta.IsStatusConditionTrue(o.Status.Conditions, "Ready"),
+			Conditions:            conditions(o.Status.Conditions),
+		}
+		// v2 keeps the installed releases in the history, latest first
+		if h := o.Status.History; r.Status.LastAppliedRevision == "" && len(h) > 0 && h[0].Status == "deployed" {
+			r.Status.LastAppliedRevision = h[0].ChartVersion
+		}
+		releases = append(releases, r)
+	}
+	return releases, err
+}
+
+// fluxReference returns the source referenced from namespace. All sources
+// are in source.toolkit.fluxcd.io.
+func fluxReference(ref fluxSourceRef, namespace string) Reference {
+	r := Reference{APIGroup: fluxSourceGroup, Kind: ref.Kind, Namespace: ref.Namespace, Name: ref.Name}
+	if r.Namespace == "" {
+		r.Namespace = namespace
+	}
+	return r
+}
+
+// linkFlux links Kustomizations and HelmReleases and the workloads labelled by
+// kustomize-controller and helm-controller as managed by them to each other
+func linkFlux(i *Inventory) {
+	workloads := make([]*inventory.Workload, 0, len(i.Workloads))
+	for _, w := range i.Workloads {
+		if w != nil {
+			workloads = append(workloads, w)
+		}
+	}
+	for _, k := range i.CustomResources.Flux.Kustomizations {
+		k.Workloads = matchWorkloads(workloads, fluxSelector(fluxKustomizeGroup, k.Name, k.Namespace))
+		for _, w := range k.Workloads {
+			l := i.workloadLinks(*w)
+			l.Flux = append(l.Flux, Reference{APIGroup: k.APIGroup, Kind: k.Kind, Namespace: k.Namespace, Name: k.Name})
+		}
+	}
+	for _, h := range i.CustomResources.Flux.HelmReleases {
+		h.Workloads = matchWorkloads(workloads, fluxSelector(fluxHelmGroup, h.Name, h.Namespace))
+		for _, w := range h.Workloads {
+			l := i.workloadLinks(*w)
+			l.Flux = append(l.Flux, Reference{APIGroup: h.APIGroup, Kind: h.Kind, Namespace: h.Namespace, Name: h.Name})
+		}
+	}
+}
+
+func fluxSelector(group, name, namespace string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{
+		group + "/name":      name,
+		group + "/namespace": namespace,
+	})
+}
@@ -0,0 +1,54 @@
+package collect
+
+import (
+	"reflect"
+	"testing"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+)
+
+func TestLinkFlux(t *testing.T) {
+	fluxLabels := map[string]string{
+		fluxKustomizeGroup + "/name":      "apps",
+		fluxKustomizeGroup + "/namespace": "flux-system",
+	}
+	deployment := inventory.NewDeployment()
+	deployment.Name, deployment.Namespace, deployment.Labels = "web", "default", fluxLabels
+	// Labels on the pod template end up on the pods
+	pod := inventory.NewPod()
+	pod.Name, pod.Namespace, pod.Labels = "web-1", "default", fluxLabels
+	pod.RootOwner = &inventory.RootOwner{Kind: "Deployment", APIGroup: "apps", APIVersion: "v1", Name: "web", Namespace: "default"}
+	other := inventory.NewStatefulSet()
+	other.Name, other.Namespace = "db", "default"
+
+	i := NewInventory()
+	i.Workloads = []*inventory.Workload{deployment, pod, other}
+	k := NewKustomization("v1")
+	k.Name, k.Namespace = "apps", "flux-system"
+	i.CustomResources.Flux.Kustomizations = []*Kustomization{k}
+	hpa := NewHorizontalPodAutoscaler()
+	hpa.Name, hpa.Namespace = "web", "default"
+	hpa.Spec.ScaleTargetRef = targetRef("apps/v1", "Deployment", "default", "web")
+	i.Availability.HorizontalPodAutoscalers = []*HorizontalPodAutoscaler{hpa}
+
+	linkHPAs(i)
+	linkFlux(i)
+
+	want := []*inventory.RootOwner{{Kind: "Deployment", APIGroup: "apps", APIVersion: "v1", Name: "web", Namespace: "default"}}
+	if !reflect.DeepEqual(k.Workloads, want) {
+		t.Errorf("workloads: got %+v, want %+v", k.Workloads, want)
+	}
+	if len(i.WorkloadLinks) != 1 {
+		t.Fatalf("got %d workload links, want 1: %+v", len(i.WorkloadLinks), i.WorkloadLinks)
+	}
+	l := i.WorkloadLinks[0]
+	if w := (Reference{APIGroup: "apps", Kind: "Deployment", Namespace: "default", Name: "web"}); l.Workload != w {
+		t.Errorf("workload: got %+v, want %+v", l.Workload, w)
+	}
+	if len(l.HorizontalPodAutoscalers) != 1 {
+		t.Errorf("autoscalers: got %+v", l.HorizontalPodAutoscalers)
+	}
+	if w := []Reference{{APIGroup: fluxKustomizeGroup, Kind: "Kustomization", Namespace: "flux-system", Name: "apps"}}; !reflect.DeepEqual(l.Flux, w) {
+		t.Errorf("flux: got %+v, want %+v", l.Flux, w)
+	}
+}
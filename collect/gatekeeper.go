@@ -0,0 +1,157 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	Register(NewCollector("gatekeeper", []string{"templates.gatekeeper.sh/v1/constrainttemplates"}, collectGatekeeper))
+}
+
+type Gatekeeper struct {
+	ConstraintTemplates []*ConstraintTemplate
+	Constraints         []*Constraint
+}
+
+type ConstraintTemplate struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 ConstraintTemplateSpec   `json:"spec"`
+	Status               ConstraintTemplateStatus `json:"status"`
+}
+
+type ConstraintTemplateSpec struct {
+	// Kind of the constraints created from the template
+	Kind    string
+	Targets []string
+}
+
+type ConstraintTemplateStatus struct {
+	// Created is whether the constraint CRD has been created
+	Created bool
+}
+
+// Constraint is an instance of a ConstraintTemplate. Its kind is the kind
+// defined by the template.
+type Constraint struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 ConstraintSpec   `json:"spec"`
+	Status               ConstraintStatus `json:"status"`
+}
+
+type ConstraintSpec struct {
+	// EnforcementAction is deny, dryrun, warn or scoped
+	EnforcementAction string
+}
+
+type ConstraintStatus struct {
+	// TotalViolations found by the last audit
+	TotalViolations int64
+	AuditTimestamp  *metav1.Time `json:",omitempty"`
+}
+
+// constraintTemplate holds the fields read from templates.gatekeeper.sh/v1
+// ConstraintTemplates
+type constraintTemplate struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		CRD struct {
+			Spec struct {
+				Names struct {
+					Kind string `json:"kind"`
+				} `json:"names"`
+			} `json:"spec"`
+		} `json:"crd"`
+		Targets []struct {
+			Target string `json:"target"`
+		} `json:"targets"`
+	} `json:"spec"`
+	Status struct {
+		Created bool `json:"created"`
+	} `json:"status"`
+}
+
+// constraint holds the fields read from constraints.gatekeeper.sh/v1beta1
+// constraints of any kind
+type constraint struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		EnforcementAction string `json:"enforcementAction"`
+	} `json:"spec"`
+	Status struct {
+		TotalViolations int64        `json:"totalViolations"`
+		AuditTimestamp  *metav1.Time `json:"auditTimestamp"`
+	} `json:"status"`
+}
+
+func NewConstraintTemplate() *ConstraintTemplate {
+	return &ConstraintTemplate{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "ConstraintTemplate",
+			APIGroup:     "templates.gatekeeper.sh",
+			APIVersion:   "v1",
+			ResourceType: "constrainttemplates",
+		},
+	}
+}
+
+func NewConstraint(kind string) *Constraint {
+	return &Constraint{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     "constraints.gatekeeper.sh",
+			APIVersion:   "v1beta1",
+			ResourceType: strings.ToLower(kind),
+		},
+	}
+}
+
+// collectGatekeeper collects the constraint templates and the constraints of
+// the kinds they define. Gatekeeper serves constraints at the lower case
+// kind.
+func collectGatekeeper(ctx context.Context, cl *Clients, i *Inventory) error {
+	var errs []error
+	templates := make([]*ConstraintTemplate, 0)
+	objs, _, err := listCustomObjects[constraintTemplate](ctx, cl.Clientset, "/apis/templates.gatekeeper.sh/v1/constrainttemplates")
+	errs = append(errs, err)
+	for _, o := range objs {
+		r := NewConstraintTemplate()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec.Kind = o.Spec.CRD.Spec.Names.Kind
+		r.Spec.Targets = make([]string, 0, len(o.Spec.Targets))
+		for _, t := range o.Spec.Targets {
+			r.Spec.Targets = append(r.Spec.Targets, t.Target)
+		}
+		r.Status.Created = o.Status.Created
+		templates = append(templates, r)
+	}
+	i.CustomResources.Gatekeeper.ConstraintTemplates = templates
+
+	constraints := make([]*Constraint, 0)
+	for _, t := range templates {
+		if t.Spec.Kind == "" || !cl.APIResources["constraints.gatekeeper.sh/v1beta1/"+strings.ToLower(t.Spec.Kind)] {
+			continue
+		}
+		objs, _, err := listCustomObjects[constraint](ctx, cl.Clientset, "/apis/constraints.gatekeeper.sh/v1beta1/"+strings.ToLower(t.Spec.Kind))
+		errs = append(errs, err)
+		for _, o := range objs {
+			r := NewConstraint(t.Spec.Kind)
+			r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+			r.Spec.EnforcementAction = "deny"
+			if o.Spec.EnforcementAction != "" {
+				r.Spec.EnforcementAction = o.Spec.EnforcementAction
+			}
+			r.Status.TotalViolations = o.Status.TotalViolations
+			r.Status.AuditTimestamp = o.Status.AuditTimestamp
+			constraints = append(constraints, r)
+		}
+	}
+	i.CustomResources.Gatekeeper.Constraints = constraints
+	return errors.Join(errs...)
+}
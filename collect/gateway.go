@@ -0,0 +1,383 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	ck "k8s.io/client-go/kubernetes"
+	gwv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func init() {
+	Register(NewCollector("gateway", []string{"gateway.networking.k8s.io/v1/gateways"}, collectGatewayAPI))
+}
+
+const gatewayGroup = "gateway.networking.k8s.io"
+
+type GatewayClass struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 GatewayClassSpec   `json:"spec"`
+	Status               GatewayClassStatus `json:"status"`
+}
+
+type GatewayClassSpec struct {
+	ControllerName string
+	Description    string     `json:",omitempty"`
+	Parameters     *Reference `json:",omitempty"`
+}
+
+type GatewayClassStatus struct {
+	Conditions []Condition
+}
+
+type Gateway struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 GatewaySpec   `json:"spec"`
+	Status               GatewayStatus `json:"status"`
+}
+
+type GatewaySpec struct {
+	GatewayClassName string
+	Listeners        []GatewayListener
+	Addresses        []string
+}
+
+type GatewayListener struct {
+	Name     string
+	Hostname string `json:",omitempty"`
+	Port     int32
+	Protocol string
+	TLS      *GatewayTLS `json:",omitempty"`
+}
+
+type GatewayTLS struct {
+	Mode            string
+	CertificateRefs []Reference
+}
+
+type GatewayStatus struct {
+	Addresses  []string
+	Conditions []Condition
+	Listeners  []GatewayListenerStatus
+}
+
+type GatewayListenerStatus struct {
+	Name           string
+	AttachedRoutes int32
+	Conditions     []Condition
+}
+
+// GatewayRoute is an HTTPRoute, GRPCRoute or TLSRoute
+type GatewayRoute struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 GatewayRouteSpec   `json:"spec"`
+	Status               GatewayRouteStatus `json:"status"`
+}
+
+type GatewayRouteSpec struct {
+	ParentRefs []RouteParent
+	Hostnames  []string
+	// Backends of all rules
+	Backends []RouteBackend
+}
+
+type RouteParent struct {
+	Reference
+	SectionName string `json:",omitempty"`
+	Port        int32  `json:",omitempty"`
+}
+
+type RouteBackend struct {
+	Reference
+	Port   int32  `json:",omitempty"`
+	Weight *int32 `json:",omitempty"`
+}
+
+type GatewayRouteStatus struct {
+	Parents []RouteParentStatus
+}
+
+type RouteParentStatus struct {
+	Parent         RouteParent
+	ControllerName string
+	Conditions     []Condition
+}
+
+func NewGatewayClass() *GatewayClass {
+	return &GatewayClass{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "GatewayClass",
+			APIGroup:     gatewayGroup,
+			APIVersion:   "v1",
+			ResourceType: "gatewayclasses",
+		},
+	}
+}
+
+func NewGateway() *Gateway {
+	return &Gateway{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "Gateway",
+			APIGroup:     gatewayGroup,
+			APIVersion:   "v1",
+			ResourceType: "gateways",
+		},
+	}
+}
+
+func NewGatewayRoute(kind, apiVersion, resourceType string) *GatewayRoute {
+	return &GatewayRoute{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     gatewayGroup,
+			APIVersion:   apiVersion,
+			ResourceType: resourceType,
+		},
+	}
+}
+
+func collectGatewayAPI(ctx context.Context, cl *Clients, i *Inventory) error {
+	cs := cl.Clientset
+	classes, classesErr := collectGatewayClasses(ctx, cs)
+	i.Networking.GatewayClasses = classes
+	gateways, gatewaysErr := collectGateways(ctx, cs)
+	i.Networking.Gateways = gateways
+	httpRoutes, httpRoutesErr := collectHTTPRoutes(ctx, cs)
+	i.Networking.HTTPRoutes = httpRoutes
+
+	// GRPCRoutes and TLSRoutes are not in the standard channel of all
+	// Gateway API releases
+	var grpcRoutesErr, tlsRoutesErr error
+	switch {
+	case cl.APIResources[gatewayGroup+"/v1/grpcroutes"]:
+		i.Networking.GRPCRoutes, grpcRoutesErr = collectGRPCRoutes(ctx, cs, "v1")
+	case cl.APIResources[gatewayGroup+"/v1alpha2/grpcroutes"]:
+		i.Networking.GRPCRoutes, grpcRoutesErr = collectGRPCRoutes(ctx, cs, "v1alpha2")
+	}
+	if cl.APIResources[gatewayGroup+"/v1alpha2/tlsroutes"] {
+		i.Networking.TLSRoutes, tlsRoutesErr = collectTLSRoutes(ctx, cs)
+	}
+	return errors.Join(classesErr, gatewaysErr, httpRoutesErr, grpcRoutesErr, tlsRoutesErr)
+}
+
+func collectGatewayClasses(ctx context.Context, cs *ck.Clientset) ([]*GatewayClass, error) {
+	classes := make([]*GatewayClass, 0)
+	list := &gwv1.GatewayClassList{}
+	if found, err := listCustomResources(ctx, cs, "/apis/gateway.networking.k8s.io/v1/gatewayclasses", list); !found {
+		return classes, err
+	}
+	for _, o := range list.Items {
+		r := NewGatewayClass()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec.ControllerName = string(o.Spec.ControllerName)
+		if o.Spec.Description != nil {
+			r.Spec.Description = *o.Spec.Description
+		}
+		if p := o.Spec.ParametersRef; p != nil {
+			r.Spec.Parameters = &Reference{APIGroup: string(p.Group), Kind: string(p.Kind), Name: p.Name}
+			if p.Namespace != nil {
+				r.Spec.Parameters.Namespace = string(*p.Namespace)
+			}
+		}
+		r.Status.Conditions = conditions(o.Status.Conditions)
+		classes = append(classes, r)
+	}
+	return classes, nil
+}
+
+func collectGateways(ctx context.Context, cs *ck.Clientset) ([]*Gateway, error) {
+	gateways := make([]*Gateway, 0)
+	list := &gwv1.GatewayList{}
+	if found, err := listCustomResources(ctx, cs, "/apis/gateway.networking.k8s.io/v1/gateways", list); !found {
+		return gateways, err
+	}
+	for _, o := range list.Items {
+		gateways = append(gateways, collectGateway(o))
+	}
+	return gateways, nil
+}
+
+func collectGateway(o gwv1.Gateway) *Gateway {
+	r := NewGateway()
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+
+	r.Spec.GatewayClassName = string(o.Spec.GatewayClassName)
+	r.Spec.Listeners = make([]GatewayListener, 0, len(o.Spec.Listeners))
+	for _, l := range o.Spec.Listeners {
+		gl := GatewayListener{
+			Name:     string(l.Name),
+			Port:     int32(l.Port),
+			Protocol: string(l.Protocol),
+		}
+		if l.Hostname != nil {
+			gl.Hostname = string(*l.Hostname)
+		}
+		if l.TLS != nil {
+			gl.TLS = &GatewayTLS{Mode: string(gwv1.TLSModeTerminate), CertificateRefs: make([]Reference, 0, len(l.TLS.CertificateRefs))}
+			if l.TLS.Mode != nil {
+				gl.TLS.Mode = string(*l.TLS.Mode)
+			}
+			for _, c := range l.TLS.CertificateRefs {
+				ref := Reference{Kind: "Secret", Namespace: o.Namespace, Name: string(c.Name)}
+				if c.Group != nil {
+					ref.APIGroup = string(*c.Group)
+				}
+				if c.Kind != nil {
+					ref.Kind = string(*c.Kind)
+				}
+				if c.Namespace != nil {
+					ref.Namespace = string(*c.Namespace)
+				}
+				gl.TLS.CertificateRefs = append(gl.TLS.CertificateRefs, ref)
+			}
+		}
+		r.Spec.Listeners = append(r.Spec.Listeners, gl)
+	}
+	r.Spec.Addresses = make([]string, 0, len(o.Spec.Addresses))
+	for _, a := range o.Spec.Addresses {
+		r.Spec.Addresses = append(r.Spec.Addresses, a.Value)
+	}
+
+	r.Status.Addresses = make([]string, 0, len(o.Status.Addresses))
+	for _, a := range o.Status.Addresses {
+		r.Status.Addresses = append(r.Status.Addresses, a.Value)
+	}
+	r.Status.Conditions = conditions(o.Status.Conditions)
+	r.Status.Listeners = make([]GatewayListenerStatus, 0, len(o.Status.Listeners))
+	for _, l := range o.Status.Listeners {
+		r.Status.Listeners = append(r.Status.Listeners, GatewayListenerStatus{
+			Name:           string(l.Name),
+			AttachedRoutes: l.AttachedRoutes,
+			Conditions:     conditions(l.Conditions),
+		})
+	}
+	return r
+}
+
+func collectHTTPRoutes(ctx context.Context, cs *ck.Clientset) ([]*GatewayRoute, error) {
+	routes := make([]*GatewayRoute, 0)
+	list := &gwv1.HTTPRouteList{}
+	if found, err := listCustomResources(ctx, cs, "/apis/gateway.networking.k8s.io/v1/httproutes", list); !found {
+		return routes, err
+	}
+	for _, o := range list.Items {
+		r := NewGatewayRoute("HTTPRoute", "v1", "httproutes")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		var backends []gwv1.BackendRef
+		for _, rule := range o.Spec.Rules {
+			for _, b := range rule.BackendRefs {
+				backends = append(backends, b.BackendRef)
+			}
+		}
+		setGatewayRoute(r, o.Namespace, o.Spec.CommonRouteSpec, o.Spec.Hostnames, backends, o.Status.RouteStatus)
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// collectGRPCRoutes reads GRPCRoutes of version. The v1alpha2 and v1 schemas
+// are the same.
+func collectGRPCRoutes(ctx context.Context, cs *ck.Clientset, version string) ([]*GatewayRoute, error) {
+	routes := make([]*GatewayRoute, 0)
+	list := &gwv1.GRPCRouteList{}
+	if found, err := listCustomResources(ctx, cs, fmt.Sprintf("/apis/gateway.networking.k8s.io/%s/grpcroutes", version), list); !found {
+		return routes, err
+	}
+	for _, o := range list.Items {
+		r := NewGatewayRoute("GRPCRoute", version, "grpcroutes")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		var backends []gwv1.BackendRef
+		for _, rule := range o.Spec.Rules {
+			for _, b := range rule.BackendRefs {
+				backends = append(backends, b.BackendRef)
+			}
+		}
+		setGatewayRoute(r, o.Namespace, o.Spec.CommonRouteSpec, o.Spec.Hostnames, backends, o.Status.RouteStatus)
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+func collectTLSRoutes(ctx context.Context, cs *ck.Clientset) ([]*GatewayRoute, error) {
+	routes := make([]*GatewayRoute, 0)
+	list := &gwv1alpha2.TLSRouteList{}
+	if found, err := listCustomResources(ctx, cs, "/apis/gateway.networking.k8s.io/v1alpha2/tlsroutes", list); !found {
+		return routes, err
+	}
+	for _, o := range list.Items {
+		r := NewGatewayRoute("TLSRoute", "v1alpha2", "tlsroutes")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		var backends []gwv1.BackendRef
+		for _, rule := range o.Spec.Rules {
+			backends = append(backends, rule.BackendRefs...)
+		}
+		setGatewayRoute(r, o.Namespace, o.Spec.CommonRouteSpec, o.Spec.Hostnames, backends, o.Status.RouteStatus)
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// setGatewayRoute sets what all kinds of routes have in common. References
+// are given with the defaults of the Gateway API filled in.
+func setGatewayRoute(r *GatewayRoute, namespace string, common gwv1.CommonRouteSpec, hostnames []gwv1.Hostname, backends []gwv1.BackendRef, status gwv1.RouteStatus) {
+	r.Spec.ParentRefs = make([]RouteParent, 0, len(common.ParentRefs))
+	for _, p := range common.ParentRefs {
+		r.Spec.ParentRefs = append(r.Spec.ParentRefs, routeParent(namespace, p))
+	}
+	r.Spec.Hostnames = make([]string, 0, len(hostnames))
+	for _, h := range hostnames {
+		r.Spec.Hostnames = append(r.Spec.Hostnames, string(h))
+	}
+	r.Spec.Backends = make([]RouteBackend, 0, len(backends))
+	for _, b := range backends {
+		rb := RouteBackend{Reference: Reference{Kind: "Service", Namespace: namespace, Name: string(b.Name)}, Weight: b.Weight}
+		if b.Group != nil {
+			rb.APIGroup = string(*b.Group)
+		}
+		if b.Kind != nil {
+			rb.Kind = string(*b.Kind)
+		}
+		if b.Namespace != nil {
+			rb.Namespace = string(*b.Namespace)
+		}
+		if b.Port != nil {
+			rb.Port = int32(*b.Port)
+		}
+		r.Spec.Backends = append(r.Spec.Backends, rb)
+	}
+
+	r.Status.Parents = make([]RouteParentStatus, 0, len(status.Parents))
+	for _, p := range status.Parents {
+		r.Status.Parents = append(r.Status.Parents, RouteParentStatus{
+			Parent:         routeParent(namespace, p.ParentRef),
+			ControllerName: string(p.ControllerName),
+			Conditions:     conditions(p.Conditions),
+		})
+	}
+}
+
+func routeParent(namespace string, p gwv1.ParentReference) RouteParent {
+	r := RouteParent{Reference: Reference{APIGroup: gatewayGroup, Kind: "Gateway", Namespace: namespace, Name: string(p.Name)}}
+	if p.Group != nil {
+		r.APIGroup = string(*p.Group)
+	}
+	if p.Kind != nil {
+		r.Kind = string(*p.Kind)
+	}
+	if p.Namespace != nil {
+		r.Namespace = string(*p.Namespace)
+	}
+	if p.SectionName != nil {
+		r.SectionName = string(*p.SectionName)
+	}
+	if p.Port != nil {
+		r.Port = int32(*p.Port)
+	}
+	return r
+}
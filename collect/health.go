@@ -0,0 +1,139 @@
+package collect
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Time allowed on top of deadlines before the client is considered stuck
+const livenessGrace = time.Minute
+
+// How long an upload may take
+const uploadTimeout = 5 * time.Minute
+
+// Maximum age of the last accepted upload for the client to be ready
+const defaultReadinessMaxUploadAge = "3h"
+
+// health tracks the progress of the collection loop. It is guarded by the
+// mutex of InventoryCollection.
+type health struct {
+	// The collection loop must check in again before deadline
+	deadline time.Time
+	// Deadlines of running collectors
+	running map[string]time.Time
+	// When the last inventory was collected
+	collected *time.Time
+}
+
+type healthStatus struct {
+	Status string   `json:"status"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// checkIn tells that the collection loop is alive and expects to check in
+// again within d
+func (c *InventoryCollection) checkIn(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.health.deadline = time.Now().Add(d + livenessGrace)
+}
+
+// collectBudget is the longest a collection and upload may take when every
+// collector runs until it times out
+func (c *InventoryCollection) collectBudget() time.Duration {
+	rounds := (len(c.collectors) + c.collectConcurrency - 1) / c.collectConcurrency
+	return time.Duration(rounds)*c.collectTimeout + uploadTimeout
+}
+
+func (c *InventoryCollection) collectorStarted(name string, deadline time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.health.running == nil {
+		c.health.running = make(map[string]time.Time)
+	}
+	c.health.running[name] = deadline
+}
+
+func (c *InventoryCollection) collectorDone(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.health.running, name)
+}
+
+func (c *InventoryCollection) collected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := time.Now()
+	c.health.collected = &t
+}
+
+// liveness returns why the collection loop is considered stuck, if it is
+func (c *InventoryCollection) liveness() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var errs []string
+	now := time.Now()
+	if d := c.health.deadline; !d.IsZero() && now.After(d) {
+		errs = append(errs, fmt.Sprintf("collection loop has not checked in for %v", now.Sub(d).Round(time.Second)))
+	}
+	for name, d := range c.health.running {
+		if now.After(d.Add(livenessGrace)) {
+			errs = append(errs, fmt.Sprintf("collector %s is running %v past its deadline", name, now.Sub(d).Round(time.Second)))
+		}
+	}
+	sort.Strings(errs)
+	return errs
+}
+
+// readiness returns why the client is not ready, if it is not
+func (c *InventoryCollection) readiness() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var errs []string
+	if c.health.collected == nil {
+		errs = append(errs, "no inventory collected yet")
+	}
+	if c.authEnabled && c.signer == nil {
+		errs = append(errs, "no signing key loaded")
+	}
+	if c.uploadInventory && c.readinessMaxUploadAge > 0 {
+		if u := c.metaData.Updated; u == nil {
+			errs = append(errs, "no upload accepted yet")
+		} else if age := time.Since(*u); age > c.readinessMaxUploadAge {
+			errs = append(errs, fmt.Sprintf("last upload accepted %v ago", age.Round(time.Second)))
+		}
+	}
+	return errs
+}
+
+// ServeHTTPLive responds with 503 Service Unavailable if the collection loop
+// or a collector is stuck
+func (c *InventoryCollection) ServeHTTPLive(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	writeHealth(w, c.liveness())
+}
+
+// ServeHTTPReady responds with 503 Service Unavailable until an inventory has
+// been collected and while the last upload is older than
+// readinessMaxUploadAge
+func (c *InventoryCollection) ServeHTTPReady(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	writeHealth(w, c.readiness())
+}
+
+func writeHealth(w http.ResponseWriter, errs []string) {
+	st := healthStatus{Status: "ok", Errors: errs}
+	w.Header().Set("Content-Type", "application/json")
+	if len(errs) > 0 {
+		st.Status = "failed"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(st); err != nil {
+		log.Error().Err(err).Msg("writing health status")
+	}
+}
@@ -0,0 +1,227 @@
+package collect
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const historySuffix = ".json.gz"
+
+// Minimum time between snapshots kept in history
+const defaultHistoryInterval = "1h"
+
+// history keeps the last snapshots in memory or, if dir is set, as gzipped
+// JSON files in dir. Snapshots published less than interval after the last
+// one kept are skipped, as are snapshots identical to it and snapshots of
+// collections that did not succeed, so they are never compared against.
+type history struct {
+	mu       sync.Mutex
+	size     int
+	dir      string
+	interval time.Duration
+	// Oldest first
+	entries []*historyEntry
+}
+
+type historyEntry struct {
+	Generation uint64    `json:"generation"`
+	Created    time.Time `json:"created"`
+	etag       string
+	snap       *snapshot
+	file       string
+}
+
+func newHistory(size int, dir string, interval time.Duration) (*history, error) {
+	h := &history{size: size, dir: dir, interval: interval}
+	if dir == "" {
+		return h, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating history directory: %v", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading history directory: %v", err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		gen, created, found := strings.Cut(strings.TrimSuffix(name, historySuffix), "-")
+		if f.IsDir() || !strings.HasSuffix(name, historySuffix) || !found {
+			continue
+		}
+		g, err := strconv.ParseUint(gen, 10, 64)
+		if err != nil {
+			continue
+		}
+		nanos, err := strconv.ParseInt(created, 10, 64)
+		if err != nil {
+			continue
+		}
+		h.entries = append(h.entries, &historyEntry{Generation: g, Created: time.Unix(0, nanos), file: filepath.Join(dir, name)})
+	}
+	sort.Slice(h.entries, func(i, j int) bool { return h.entries[i].Generation < h.entries[j].Generation })
+	h.prune()
+	return h, nil
+}
+
+// lastGeneration returns the generation of the newest snapshot kept
+func (h *history) lastGeneration() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.entries) == 0 {
+		return 0
+	}
+	return h.entries[len(h.entries)-1].Generation
+}
+
+func (h *history) add(s *snapshot) {
+	if !s.Inventory.CollectionSucceeded {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if n := len(h.entries); n > 0 {
+		last := h.entries[n-1]
+		if last.etag == s.ETag || s.Created.Sub(last.Created) < h.interval {
+			return
+		}
+	}
+
+	e := &historyEntry{Generation: s.Generation, Created: s.Created, etag: s.ETag}
+	if h.dir == "" {
+		e.snap = s
+	} else {
+		file, err := h.write(s)
+		if err != nil {
+			log.Error().Err(err).Msg("writing history")
+			return
+		}
+		e.file = file
+	}
+	h.entries = append(h.entries, e)
+	h.prune()
+}
+
+func (h *history) write(s *snapshot) (string, error) {
+	data, err := s.Gzipped()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(h.dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	file := filepath.Join(h.dir, fmt.Sprintf("%020d-%d%s", s.Generation, s.Created.UnixNano(), historySuffix))
+	return file, os.Rename(tmp.Name(), file)
+}
+
+// prune drops the oldest entries beyond size. h.mu must be held.
+func (h *history) prune() {
+	for len(h.entries) > h.size {
+		if f := h.entries[0].file; f != "" {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				log.Error().Err(err).Msg("removing history")
+			}
+		}
+		h.entries = h.entries[1:]
+	}
+}
+
+func (h *history) list() []historyEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l := make([]historyEntry, 0, len(h.entries))
+	for _, e := range h.entries {
+		l = append(l, *e)
+	}
+	return l
+}
+
+// find returns the snapshot referred to by ref, which is a generation, a
+// time in RFC 3339 format or a duration before now. For times the newest
+// snapshot created at or before that time is returned. current is considered
+// as well if it succeeded, as it may not have been kept.
+func (h *history) find(ref string, current *snapshot) (*snapshot, error) {
+	h.mu.Lock()
+	candidates := append([]*historyEntry{}, h.entries...)
+	h.mu.Unlock()
+	if current != nil && current.Inventory.CollectionSucceeded {
+		candidates = append(candidates, &historyEntry{Generation: current.Generation, Created: current.Created, snap: current})
+	}
+
+	var entry *historyEntry
+	if gen, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		for _, e := range candidates {
+			if e.Generation == gen {
+				entry = e
+			}
+		}
+	} else {
+		var t time.Time
+		if d, err := time.ParseDuration(ref); err == nil {
+			t = time.Now().Add(-d)
+		} else if t, err = time.Parse(time.RFC3339, ref); err != nil {
+			return nil, fmt.Errorf("%q is not a generation, time or duration", ref)
+		}
+		for _, e := range candidates {
+			if !e.Created.After(t) {
+				entry = e
+			}
+		}
+	}
+	if entry == nil {
+		return nil, errHistoryNotFound
+	}
+	if entry.snap != nil {
+		return entry.snap, nil
+	}
+	return loadSnapshot(entry)
+}
+
+var errHistoryNotFound = fmt.Errorf("no such snapshot in history")
+
+func loadSnapshot(e *historyEntry) (*snapshot, error) {
+	f, err := os.Open(filepath.Clean(e.file))
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %v", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %v", err)
+	}
+	i := NewInventory()
+	if err := json.Unmarshal(data, i); err != nil {
+		return nil, fmt.Errorf("decoding history: %v", err)
+	}
+	return &snapshot{
+		Generation: e.Generation,
+		Created:    e.Created,
+		Inventory:  i,
+		JSON:       data,
+		ETag:       `"` + hash(data) + `"`,
+	}, nil
+}
@@ -0,0 +1,248 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func init() {
+	Register(WithLink(NewCollector("ingress", []string{"networking.k8s.io/v1/ingresses"}, collectIngresses), linkIngresses))
+}
+
+type Ingress struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 IngressSpec   `json:"spec"`
+	Status               IngressStatus `json:"status"`
+	// Workloads behind the services the ingress routes to
+	Workloads []*inventory.RootOwner `json:",omitempty"`
+}
+
+type IngressSpec struct {
+	IngressClassName string
+	// Hosts of all rules
+	Hosts          []string
+	TLS            []IngressTLS
+	DefaultBackend *IngressBackend
+	Rules          []IngressRule
+}
+
+type IngressTLS struct {
+	Hosts      []string
+	SecretName string
+}
+
+type IngressRule struct {
+	Host  string
+	Paths []IngressPath
+}
+
+type IngressPath struct {
+	Path     string
+	PathType string
+	Backend  IngressBackend
+}
+
+// IngressBackend is either a service and port or a resource given as
+// apiGroup/kind/name
+type IngressBackend struct {
+	Service  string `json:",omitempty"`
+	Port     string `json:",omitempty"`
+	Resource string `json:",omitempty"`
+}
+
+type IngressStatus struct {
+	LoadBalancer []LoadBalancerIngress
+}
+
+type IngressClass struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 IngressClassSpec `json:"spec"`
+}
+
+type IngressClassSpec struct {
+	Controller string
+	// Parameters is given as apiGroup/kind/namespace/name
+	Parameters string
+	Default    bool
+}
+
+func NewIngress() *Ingress {
+	return &Ingress{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "Ingress",
+			APIGroup:     "networking.k8s.io",
+			APIVersion:   "v1",
+			ResourceType: "ingresses",
+		},
+	}
+}
+
+func NewIngressClass() *IngressClass {
+	return &IngressClass{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "IngressClass",
+			APIGroup:     "networking.k8s.io",
+			APIVersion:   "v1",
+			ResourceType: "ingressclasses",
+		},
+	}
+}
+
+func collectIngresses(ctx context.Context, cl *Clients, i *Inventory) error {
+	ingresses := make([]*Ingress, 0)
+	ingressList := &networkingv1.IngressList{}
+	if err := cl.Client.List(ctx, ingressList); err != nil {
+		return fmt.Errorf("getting Ingresses: %v", err)
+	}
+	for _, o := range ingressList.Items {
+		ingresses = append(ingresses, collectIngress(o))
+	}
+	i.Networking.Ingresses = ingresses
+
+	classes, classesErr := collectIngressClasses(ctx, cl)
+	i.Networking.IngressClasses = classes
+	return classesErr
+}
+
+func collectIngress(o networkingv1.Ingress) *Ingress {
+	r := NewIngress()
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+
+	if o.Spec.IngressClassName != nil {
+		r.Spec.IngressClassName = *o.Spec.IngressClassName
+	} else if class, found := o.Annotations["kubernetes.io/ingress.class"]; found {
+		// Deprecated, but still in use
+		r.Spec.IngressClassName = class
+	}
+	if o.Spec.DefaultBackend != nil {
+		b := ingressBackend(*o.Spec.DefaultBackend)
+		r.Spec.DefaultBackend = &b
+	}
+	hosts := make(map[string]bool)
+	r.Spec.Rules = make([]IngressRule, 0, len(o.Spec.Rules))
+	for _, rule := range o.Spec.Rules {
+		ir := IngressRule{Host: rule.Host, Paths: make([]IngressPath, 0)}
+		if rule.Host != "" {
+			hosts[rule.Host] = true
+		}
+		if rule.HTTP != nil {
+			for _, p := range rule.HTTP.Paths {
+				ip := IngressPath{Path: p.Path, Backend: ingressBackend(p.Backend)}
+				if p.PathType != nil {
+					ip.PathType = string(*p.PathType)
+				}
+				ir.Paths = append(ir.Paths, ip)
+			}
+		}
+		r.Spec.Rules = append(r.Spec.Rules, ir)
+	}
+	r.Spec.TLS = make([]IngressTLS, 0, len(o.Spec.TLS))
+	for _, t := range o.Spec.TLS {
+		r.Spec.TLS = append(r.Spec.TLS, IngressTLS{Hosts: t.Hosts, SecretName: t.SecretName})
+		for _, h := range t.Hosts {
+			hosts[h] = true
+		}
+	}
+	r.Spec.Hosts = make([]string, 0, len(hosts))
+	for h := range hosts {
+		r.Spec.Hosts = append(r.Spec.Hosts, h)
+	}
+	sort.Strings(r.Spec.Hosts)
+
+	r.Status.LoadBalancer = make([]LoadBalancerIngress, 0, len(o.Status.LoadBalancer.Ingress))
+	for _, lb := range o.Status.LoadBalancer.Ingress {
+		r.Status.LoadBalancer = append(r.Status.LoadBalancer, LoadBalancerIngress{IP: lb.IP, Hostname: lb.Hostname})
+	}
+	return r
+}
+
+func ingressBackend(b networkingv1.IngressBackend) IngressBackend {
+	var r IngressBackend
+	if b.Service != nil {
+		r.Service = b.Service.Name
+		r.Port = b.Service.Port.Name
+		if r.Port == "" {
+			r.Port = strconv.Itoa(int(b.Service.Port.Number))
+		}
+	}
+	if b.Resource != nil {
+		group := "core"
+		if b.Resource.APIGroup != nil && *b.Resource.APIGroup != "" {
+			group = *b.Resource.APIGroup
+		}
+		r.Resource = fmt.Sprintf("%s/%s/%s", group, b.Resource.Kind, b.Resource.Name)
+	}
+	return r
+}
+
+func collectIngressClasses(ctx context.Context, cl *Clients) ([]*IngressClass, error) {
+	classes := make([]*IngressClass, 0)
+	if !cl.APIResources["networking.k8s.io/v1/ingressclasses"] {
+		return classes, nil
+	}
+	classList := &networkingv1.IngressClassList{}
+	if err := cl.Client.List(ctx, classList); err != nil {
+		return nil, fmt.Errorf("getting IngressClasses: %v", err)
+	}
+	for _, o := range classList.Items {
+		r := NewIngressClass()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec.Controller = o.Spec.Controller
+		r.Spec.Default = o.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true"
+		if p := o.Spec.Parameters; p != nil {
+			group := "core"
+			if p.APIGroup != nil && *p.APIGroup != "" {
+				group = *p.APIGroup
+			}
+			namespace := ""
+			if p.Namespace != nil {
+				namespace = *p.Namespace
+			}
+			r.Spec.Parameters = fmt.Sprintf("%s/%s/%s/%s", group, p.Kind, namespace, p.Name)
+		}
+		classes = append(classes, r)
+	}
+	return classes, nil
+}
+
+// linkIngresses links ingresses to the workloads selected by the services
+// they route to
+func linkIngresses(i *Inventory) {
+	pods := podsByNamespace(i)
+	services := make(map[string]*Service)
+	for _, s := range i.Networking.Services {
+		services[s.Namespace+"/"+s.Name] = s
+	}
+	for _, ing := range i.Networking.Ingresses {
+		backends := make([]IngressBackend, 0)
+		if ing.Spec.DefaultBackend != nil {
+			backends = append(backends, *ing.Spec.DefaultBackend)
+		}
+		for _, r := range ing.Spec.Rules {
+			for _, p := range r.Paths {
+				backends = append(backends, p.Backend)
+			}
+		}
+
+		seen := make(map[inventory.RootOwner]bool)
+		for _, b := range backends {
+			s, found := services[ing.Namespace+"/"+b.Service]
+			if b.Service == "" || !found {
+				continue
+			}
+			for _, w := range selectWorkloads(pods[ing.Namespace], s.Spec.Selector) {
+				if !seen[*w] {
+					seen[*w] = true
+					ing.Workloads = append(ing.Workloads, w)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package collect
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+)
+
+// Inventory is the inventory built by collectors. It extends the shared
+// inventory model with sections collected by this client only. The sections
+// are encoded next to those of the shared model, so servers not knowing them
+// can still decode the inventory. Sections replacing those of the shared
+// model are encoded in their place, under the same key.
+type Inventory struct {
+	*inventory.Inventory
+	// Namespaces replaces the namespaces of the shared model, which it extends
+	Namespaces      []*Namespace
+	PriorityClasses []*PriorityClass
+	// Storage replaces the storage section of the shared model, which it
+	// encodes the same way with more fields
+	Storage Storage
+	// CustomResources replaces the custom resources section of the shared
+	// model, which it extends
+	CustomResources CustomResources
+	Networking      Networking
+	RBAC            RBAC
+	Availability    Availability
+	Admission       Admission
+	// WorkloadLinks lists what is linked to the workloads, which are of the
+	// shared model and have no room for it
+	WorkloadLinks []*WorkloadLinks
+	// CollectorErrors details the collectors which failed. Their messages are
+	// also in CollectionErrors.
+	CollectorErrors []CollectorError
+
+	// podClaims holds the claims mounted by each pod, keyed by namespace and
+	// pod name. It is nil if the pods could not be listed.
+	podClaims map[string][]string
+	// workloadLinkIndex indexes WorkloadLinks by workload
+	workloadLinkIndex map[Reference]*WorkloadLinks
+}
+
+// Storage is the persistent storage of the cluster
+type Storage struct {
+	PersistentVolumes      []*inventory.PersistentVolume
+	StorageClasses         []*StorageClass
+	PersistentVolumeClaims []*PersistentVolumeClaim
+	CSIDrivers             []*CSIDriver
+	CSINodes               []*CSINode
+	VolumeAttachments      []*VolumeAttachment
+	VolumeSnapshotClasses  []*VolumeSnapshotClass
+	VolumeSnapshots        []*VolumeSnapshot
+	// Reclaimable lists claims and volumes which are likely no longer used
+	Reclaimable []*ReclaimableStorage
+}
+
+// CustomResources is what is known about operators and components installed
+// by custom resource definitions
+type CustomResources struct {
+	inventory.CustomResources
+	HasKyverno    bool
+	HasGatekeeper bool
+	Kyverno       Kyverno
+	Gatekeeper    Gatekeeper
+	CertManager   CertManager
+	Flux          Flux
+}
+
+// Networking is how workloads are exposed
+type Networking struct {
+	Services       []*Service
+	Ingresses      []*Ingress
+	IngressClasses []*IngressClass
+	GatewayClasses []*GatewayClass
+	Gateways       []*Gateway
+	HTTPRoutes     []*GatewayRoute
+	GRPCRoutes     []*GatewayRoute
+	TLSRoutes      []*GatewayRoute
+	HTTPProxies    []*HTTPProxy
+}
+
+// RBAC is who can do what in the cluster
+type RBAC struct {
+	ServiceAccounts     []*ServiceAccount
+	Roles               []*Role
+	ClusterRoles        []*Role
+	RoleBindings        []*RoleBinding
+	ClusterRoleBindings []*RoleBinding
+	// GroupRules are the rules granted to groups of service accounts, which
+	// apply to every service account in the group
+	GroupRules []GroupRule
+}
+
+// Availability is how workloads scale and are protected against disruption
+type Availability struct {
+	HorizontalPodAutoscalers []*HorizontalPodAutoscaler
+	VerticalPodAutoscalers   []*VerticalPodAutoscaler
+	PodDisruptionBudgets     []*PodDisruptionBudget
+}
+
+// Admission is how requests to the API server are validated and mutated
+type Admission struct {
+	MutatingWebhookConfigurations     []*WebhookConfiguration
+	ValidatingWebhookConfigurations   []*WebhookConfiguration
+	ValidatingAdmissionPolicies       []*ValidatingAdmissionPolicy
+	ValidatingAdmissionPolicyBindings []*ValidatingAdmissionPolicyBinding
+}
+
+// NewInventory returns an empty inventory
+func NewInventory() *Inventory {
+	return &Inventory{Inventory: inventory.NewInventory()}
+}
+
+// sharedSectionKeys maps the fields of the shared model to their JSON keys
+var sharedSectionKeys = jsonKeys(reflect.TypeOf(inventory.Inventory{}))
+
+func jsonKeys(t reflect.Type) map[string]string {
+	keys := make(map[string]string, t.NumField())
+	for n := 0; n < t.NumField(); n++ {
+		f := t.Field(n)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			name = f.Name
+		}
+		keys[f.Name] = name
+	}
+	return keys
+}
+
+// sectionKey returns the JSON key of the section held by field
+func sectionKey(field string) string {
+	if key, found := sharedSectionKeys[field]; found {
+		return key
+	}
+	return field
+}
+
+// MarshalJSON encodes the sections of the shared model and those of i as
+// one object, with one key per section
+func (i Inventory) MarshalJSON() ([]byte, error) {
+	sections := make(map[string]json.RawMessage)
+	if i.Inventory != nil {
+		data, err := json.Marshal(i.Inventory)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &sections); err != nil {
+			return nil, err
+		}
+	}
+	v := reflect.ValueOf(i)
+	for n := 0; n < v.NumField(); n++ {
+		if f := v.Type().Field(n); f.Anonymous || !f.IsExported() {
+			continue
+		}
+		data, err := json.Marshal(v.Field(n).Interface())
+		if err != nil {
+			return nil, err
+		}
+		sections[sectionKey(v.Type().Field(n).Name)] = data
+	}
+	return json.Marshal(sections)
+}
+
+// UnmarshalJSON decodes an inventory encoded by MarshalJSON
+func (i *Inventory) UnmarshalJSON(data []byte) error {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(i).Elem()
+	for n := 0; n < v.NumField(); n++ {
+		f := v.Type().Field(n)
+		if f.Anonymous || !f.IsExported() {
+			continue
+		}
+		key := sectionKey(f.Name)
+		if raw, found := sections[key]; found {
+			if err := json.Unmarshal(raw, v.Field(n).Addr().Interface()); err != nil {
+				return err
+			}
+			// Replaced sections are only decoded once, in their extended form
+			delete(sections, key)
+		}
+	}
+	shared, err := json.Marshal(sections)
+	if err != nil {
+		return err
+	}
+	if i.Inventory == nil {
+		i.Inventory = inventory.NewInventory()
+	}
+	return json.Unmarshal(shared, i.Inventory)
+}
@@ -0,0 +1,82 @@
+package collect
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+)
+
+// topLevelKeys returns the keys of the JSON object in data in order,
+// including duplicates
+func topLevelKeys(t *testing.T, data []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		t.Fatalf("expected an object, got %v %v", tok, err)
+	}
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, tok.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return keys
+}
+
+func TestInventoryMarshalJSON(t *testing.T) {
+	i := NewInventory()
+	ns := &Namespace{Namespace: *inventory.NewNamespace()}
+	ns.Name = "default"
+	i.Namespaces = []*Namespace{ns}
+	i.Storage.PersistentVolumeClaims = []*PersistentVolumeClaim{NewPersistentVolumeClaim()}
+	i.CustomResources.HasKyverno = true
+	i.Workloads = []*inventory.Workload{}
+
+	data, err := json.Marshal(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := topLevelKeys(t, data)
+	seen := make(map[string]int)
+	for _, k := range keys {
+		seen[k]++
+	}
+	for k, n := range seen {
+		if n != 1 {
+			t.Errorf("key %q encoded %d times", k, n)
+		}
+	}
+
+	sections := []string{"Namespaces", "Storage", "CustomResources", "Workloads", "PriorityClasses", "Networking", "RBAC", "Availability", "Admission", "CollectorErrors"}
+	for _, s := range sections {
+		if seen[sectionKey(s)] != 1 {
+			t.Errorf("section %s not encoded", s)
+		}
+	}
+
+	decoded := NewInventory()
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Namespaces, i.Namespaces) {
+		t.Errorf("namespaces: got %+v, want %+v", decoded.Namespaces, i.Namespaces)
+	}
+	if len(decoded.Storage.PersistentVolumeClaims) != 1 {
+		t.Errorf("expected the claim to be decoded, got %+v", decoded.Storage)
+	}
+	if !decoded.CustomResources.HasKyverno {
+		t.Errorf("expected the extended custom resources to be decoded")
+	}
+	if decoded.Inventory.Workloads == nil {
+		t.Errorf("expected the workloads of the shared model to be decoded")
+	}
+}
@@ -8,19 +8,16 @@ import (
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/batch/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectJobs(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectJobs(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	jobs := make([]*inventory.Workload, 0)
-	options := metav1.ListOptions{Limit: 500}
+	options := &client.ListOptions{Limit: 500}
 	var errs []error
 	for {
-		jobList, err := cs.BatchV1().
-			Jobs("").
-			List(ctx, options)
+		jobList := &v1.JobList{}
+		err := kc.List(ctx, jobList, options)
 		if err != nil && !k8serrors.IsNotFound(err) {
 			return nil, fmt.Errorf("getting Jobs/v1: %v", err)
 		}
@@ -33,7 +30,7 @@ func collectJobs(ctx context.Context, cs *ck.Clientset, client client.Client) ([
 					}
 				}
 			}
-			job, err := collectJob(ctx, client, o)
+			job, err := collectJob(ctx, kc, o)
 			errs = append(errs, err)
 			jobs = append(jobs, job)
 		}
@@ -1,15 +1,27 @@
 package collect
 
 import (
+	"context"
+
 	dboperatorapi "github.com/db-operator/db-operator/api/v1alpha1"
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	"github.com/neticdk-k8s/k8s-inventory-client/kubernetes"
 	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectKCIRocksDBInstances(cs *ck.Clientset) ([]*inventory.KCIRocksDBInstance, error) {
+func init() {
+	Register(NewCollector("kci_rocks", []string{"kci.rocks/v1alpha1/dbinstances"}, collectKCIRocks))
+}
+
+func collectKCIRocks(ctx context.Context, cl *Clients, i *Inventory) error {
+	instances, err := collectKCIRocksDBInstances(ctx, cl.Clientset)
+	i.CustomResources.KCIRocks.DBInstances = instances
+	return err
+}
+
+func collectKCIRocksDBInstances(ctx context.Context, cs *ck.Clientset) ([]*inventory.KCIRocksDBInstance, error) {
 	instances := make([]*inventory.KCIRocksDBInstance, 0)
-	res, found, err := kubernetes.GetK8SRESTResource(cs, "/apis/kci.rocks/v1alpha1/dbinstances")
+	res, found, err := kubernetes.GetK8SRESTResource(ctx, cs, "/apis/kci.rocks/v1alpha1/dbinstances")
 	if err != nil {
 		return nil, err
 	}
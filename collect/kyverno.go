@@ -0,0 +1,205 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ck "k8s.io/client-go/kubernetes"
+)
+
+func init() {
+	Register(NewCollector("kyverno", []string{"kyverno.io/v1/clusterpolicies"}, collectKyverno))
+}
+
+type Kyverno struct {
+	ClusterPolicies []*KyvernoPolicy
+	Policies        []*KyvernoPolicy
+	// PolicyReports sums the results of the policy reports by namespace
+	PolicyReports []PolicyReportSummary
+}
+
+// KyvernoPolicy is a Kyverno ClusterPolicy or Policy
+type KyvernoPolicy struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 KyvernoPolicySpec   `json:"spec"`
+	Status               KyvernoPolicyStatus `json:"status"`
+}
+
+type KyvernoPolicySpec struct {
+	// ValidationFailureAction is Audit or Enforce
+	ValidationFailureAction string
+	Background              bool
+	Rules                   []KyvernoRule
+}
+
+type KyvernoRule struct {
+	Name string
+	// Type is validate, mutate, generate or verifyImages
+	Type string
+	// FailureAction overrides the ValidationFailureAction of the policy
+	FailureAction string `json:",omitempty"`
+}
+
+type KyvernoPolicyStatus struct {
+	Ready      bool
+	Conditions []Condition
+}
+
+// PolicyReportSummary sums the results of the PolicyReports of a namespace,
+// or of the ClusterPolicyReports if Namespace is empty
+type PolicyReportSummary struct {
+	Namespace string `json:",omitempty"`
+	Reports   int
+	Pass      int
+	Fail      int
+	Warn      int
+	Error     int
+	Skip      int
+}
+
+// kyvernoPolicy holds the fields read from kyverno.io/v1 ClusterPolicies and
+// Policies
+type kyvernoPolicy struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		ValidationFailureAction string `json:"validationFailureAction"`
+		Background              *bool  `json:"background"`
+		Rules                   []struct {
+			Name     string `json:"name"`
+			Validate *struct {
+				FailureAction string `json:"failureAction"`
+			} `json:"validate"`
+			Mutate       *struct{} `json:"mutate"`
+			Generate     *struct{} `json:"generate"`
+			VerifyImages []any     `json:"verifyImages"`
+		} `json:"rules"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []metav1.Condition `json:"conditions"`
+	} `json:"status"`
+}
+
+// policyReport holds the fields read from wgpolicyk8s.io/v1alpha2
+// PolicyReports and ClusterPolicyReports
+type policyReport struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Summary           struct {
+		Pass  int `json:"pass"`
+		Fail  int `json:"fail"`
+		Warn  int `json:"warn"`
+		Error int `json:"error"`
+		Skip  int `json:"skip"`
+	} `json:"summary"`
+}
+
+func NewKyvernoPolicy(kind, resourceType string) *KyvernoPolicy {
+	return &KyvernoPolicy{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     "kyverno.io",
+			APIVersion:   "v1",
+			ResourceType: resourceType,
+		},
+	}
+}
+
+func collectKyverno(ctx context.Context, cl *Clients, i *Inventory) error {
+	clusterPolicies, clusterPoliciesErr := collectKyvernoPolicies(ctx, cl.Clientset, "ClusterPolicy", "clusterpolicies")
+	i.CustomResources.Kyverno.ClusterPolicies = clusterPolicies
+	policies, policiesErr := collectKyvernoPolicies(ctx, cl.Clientset, "Policy", "policies")
+	i.CustomResources.Kyverno.Policies = policies
+	reports, reportsErr := collectPolicyReports(ctx, cl)
+	i.CustomResources.Kyverno.PolicyReports = reports
+	return errors.Join(clusterPoliciesErr, policiesErr, reportsErr)
+}
+
+func collectKyvernoPolicies(ctx context.Context, cs *ck.Clientset, kind, resourceType string) ([]*KyvernoPolicy, error) {
+	policies := make([]*KyvernoPolicy, 0)
+	objs, _, err := listCustomObjects[kyvernoPolicy](ctx, cs, "/apis/kyverno.io/v1/"+resourceType)
+	for _, o := range objs {
+		r := NewKyvernoPolicy(kind, resourceType)
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Spec.ValidationFailureAction = failureAction(o.Spec.ValidationFailureAction)
+		if r.Spec.ValidationFailureAction == "" {
+			r.Spec.ValidationFailureAction = "Audit"
+		}
+		r.Spec.Background = o.Spec.Background == nil || *o.Spec.Background
+		r.Spec.Rules = make([]KyvernoRule, 0, len(o.Spec.Rules))
+		for _, rule := range o.Spec.Rules {
+			kr := KyvernoRule{Name: rule.Name}
+			switch {
+			case rule.Validate != nil:
+				kr.Type = "validate"
+				kr.FailureAction = failureAction(rule.Validate.FailureAction)
+			case rule.Mutate != nil:
+				kr.Type = "mutate"
+			case rule.Generate != nil:
+				kr.Type = "generate"
+			case len(rule.VerifyImages) > 0:
+				kr.Type = "verifyImages"
+			}
+			r.Spec.Rules = append(r.Spec.Rules, kr)
+		}
+		r.Status.Ready = meta.IsStatusConditionTrue(o.Status.Conditions, "Ready")
+		r.Status.Conditions = conditions(o.Status.Conditions)
+		policies = append(policies, r)
+	}
+	return policies, err
+}
+
+// failureAction returns a validation failure action as Audit or Enforce.
+// Kyverno also accepts the deprecated lowercase forms.
+func failureAction(action string) string {
+	switch strings.ToLower(action) {
+	case "audit":
+		return "Audit"
+	case "enforce":
+		return "Enforce"
+	}
+	return action
+}
+
+// collectPolicyReports sums the policy reports by namespace. The sum of the
+// cluster policy reports has an empty namespace and is listed first. Reports
+// are served by wgpolicyk8s.io, which may be missing even if Kyverno is
+// installed.
+func collectPolicyReports(ctx context.Context, cl *Clients) ([]PolicyReportSummary, error) {
+	summaries := make(map[string]*PolicyReportSummary)
+	add := func(reports []policyReport) {
+		for _, o := range reports {
+			s := summaries[o.Namespace]
+			if s == nil {
+				s = &PolicyReportSummary{Namespace: o.Namespace}
+				summaries[o.Namespace] = s
+			}
+			s.Reports++
+			s.Pass += o.Summary.Pass
+			s.Fail += o.Summary.Fail
+			s.Warn += o.Summary.Warn
+			s.Error += o.Summary.Error
+			s.Skip += o.Summary.Skip
+		}
+	}
+	var errs []error
+	for _, resourceType := range []string{"clusterpolicyreports", "policyreports"} {
+		if !cl.APIResources["wgpolicyk8s.io/v1alpha2/"+resourceType] {
+			continue
+		}
+		reports, _, err := listCustomObjects[policyReport](ctx, cl.Clientset, "/apis/wgpolicyk8s.io/v1alpha2/"+resourceType)
+		add(reports)
+		errs = append(errs, err)
+	}
+
+	l := make([]PolicyReportSummary, 0, len(summaries))
+	for _, s := range summaries {
+		l = append(l, *s)
+	}
+	sort.Slice(l, func(a, b int) bool { return l[a].Namespace < l[b].Namespace })
+	return l, errors.Join(errs...)
+}
@@ -0,0 +1,161 @@
+package collect
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "k8s_inventory"
+
+var (
+	collectorDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "collector_duration_seconds",
+		Help:      "Time spent running a collector.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+	}, []string{"collector"})
+	collectorRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "collector_runs_total",
+		Help:      "Number of collector runs by result, which is success, error or timeout.",
+	}, []string{"collector", "result"})
+	lastCollection = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_successful_collection_timestamp_seconds",
+		Help:      "Time of the last collection where all collectors succeeded.",
+	})
+	lastUpload = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_successful_upload_timestamp_seconds",
+		Help:      "Time of the last upload accepted by the inventory server.",
+	})
+	uploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "uploads_total",
+		Help:      "Number of uploads by type, full or delta, and HTTP status, or error if no response was received.",
+	}, []string{"type", "status"})
+	uploadBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "upload_bytes_total",
+		Help:      "Compressed bytes sent to the inventory server by type of upload.",
+	}, []string{"type"})
+	certificateExpiry = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "certificate_expiry_timestamp_seconds",
+		Help:      "Expiry of the certificate used to sign uploads.",
+	})
+	inventoryObjects = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "objects",
+		Help:      "Number of objects in the last collected inventory by section.",
+	}, []string{"section"})
+	inventoryWorkloads = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "workloads",
+		Help:      "Number of workloads in the last collected inventory by kind.",
+	}, []string{"kind"})
+)
+
+func observeCollector(name string, d time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+		var colErr CollectorError
+		if errors.As(err, &colErr) && colErr.Timeout {
+			result = "timeout"
+		}
+	}
+	collectorDuration.WithLabelValues(name).Observe(d.Seconds())
+	collectorRuns.WithLabelValues(name, result).Inc()
+}
+
+func observeUpload(method string, bytes int, statusCode int, err error) {
+	typ := "full"
+	if method == "PATCH" {
+		typ = "delta"
+	}
+	status := "error"
+	if statusCode != 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	uploads.WithLabelValues(typ, status).Inc()
+	uploadBytes.WithLabelValues(typ).Add(float64(bytes))
+	if err == nil {
+		lastUpload.SetToCurrentTime()
+	}
+}
+
+func observeInventory(i *Inventory) {
+	if i.CollectionSucceeded {
+		lastCollection.SetToCurrentTime()
+	}
+	for section, n := range map[string]int{
+		"namespaces":                               len(i.Namespaces),
+		"priority_classes":                         len(i.PriorityClasses),
+		"nodes":                                    len(i.Nodes),
+		"workloads":                                len(i.Workloads),
+		"network_policies":                         len(i.NetworkPolicies),
+		"persistent_volumes":                       len(i.Storage.PersistentVolumes),
+		"storage_classes":                          len(i.Storage.StorageClasses),
+		"persistent_volume_claims":                 len(i.Storage.PersistentVolumeClaims),
+		"reclaimable_storage":                      len(i.Storage.Reclaimable),
+		"csi_drivers":                              len(i.Storage.CSIDrivers),
+		"csi_nodes":                                len(i.Storage.CSINodes),
+		"volume_attachments":                       len(i.Storage.VolumeAttachments),
+		"volume_snapshot_classes":                  len(i.Storage.VolumeSnapshotClasses),
+		"volume_snapshots":                         len(i.Storage.VolumeSnapshots),
+		"velero_backups":                           len(i.CustomResources.Velero.Backups),
+		"velero_schedules":                         len(i.CustomResources.Velero.Schedules),
+		"kci_rocks_db_instances":                   len(i.CustomResources.KCIRocks.DBInstances),
+		"rabbitmq_clusters":                        len(i.CustomResources.RabbitMQ.Clusters),
+		"kyverno_cluster_policies":                 len(i.CustomResources.Kyverno.ClusterPolicies),
+		"kyverno_policies":                         len(i.CustomResources.Kyverno.Policies),
+		"gatekeeper_constraint_templates":          len(i.CustomResources.Gatekeeper.ConstraintTemplates),
+		"gatekeeper_constraints":                   len(i.CustomResources.Gatekeeper.Constraints),
+		"cert_manager_issuers":                     len(i.CustomResources.CertManager.Issuers),
+		"cert_manager_cluster_issuers":             len(i.CustomResources.CertManager.ClusterIssuers),
+		"cert_manager_certificates":                len(i.CustomResources.CertManager.Certificates),
+		"cert_manager_expiring_certificates":       len(i.CustomResources.CertManager.Expiring),
+		"cert_manager_not_ready_certificates":      len(i.CustomResources.CertManager.NotReady),
+		"cert_manager_failed_certificate_requests": len(i.CustomResources.CertManager.FailedCertificateRequests),
+		"flux_git_repositories":                    len(i.CustomResources.Flux.GitRepositories),
+		"flux_oci_repositories":                    len(i.CustomResources.Flux.OCIRepositories),
+		"flux_helm_repositories":                   len(i.CustomResources.Flux.HelmRepositories),
+		"flux_kustomizations":                      len(i.CustomResources.Flux.Kustomizations),
+		"flux_helm_releases":                       len(i.CustomResources.Flux.HelmReleases),
+		"services":                                 len(i.Networking.Services),
+		"ingresses":                                len(i.Networking.Ingresses),
+		"ingress_classes":                          len(i.Networking.IngressClasses),
+		"gateway_classes":                          len(i.Networking.GatewayClasses),
+		"gateways":                                 len(i.Networking.Gateways),
+		"http_routes":                              len(i.Networking.HTTPRoutes),
+		"grpc_routes":                              len(i.Networking.GRPCRoutes),
+		"tls_routes":                               len(i.Networking.TLSRoutes),
+		"http_proxies":                             len(i.Networking.HTTPProxies),
+		"horizontal_pod_autoscalers":               len(i.Availability.HorizontalPodAutoscalers),
+		"vertical_pod_autoscalers":                 len(i.Availability.VerticalPodAutoscalers),
+		"pod_disruption_budgets":                   len(i.Availability.PodDisruptionBudgets),
+		"service_accounts":                         len(i.RBAC.ServiceAccounts),
+		"roles":                                    len(i.RBAC.Roles),
+		"cluster_roles":                            len(i.RBAC.ClusterRoles),
+		"role_bindings":                            len(i.RBAC.RoleBindings),
+		"cluster_role_bindings":                    len(i.RBAC.ClusterRoleBindings),
+		"mutating_webhook_configurations":          len(i.Admission.MutatingWebhookConfigurations),
+		"validating_webhook_configurations":        len(i.Admission.ValidatingWebhookConfigurations),
+		"validating_admission_policies":            len(i.Admission.ValidatingAdmissionPolicies),
+		"validating_admission_policy_bindings":     len(i.Admission.ValidatingAdmissionPolicyBindings),
+	} {
+		inventoryObjects.WithLabelValues(section).Set(float64(n))
+	}
+
+	inventoryWorkloads.Reset()
+	for _, w := range i.Workloads {
+		if w != nil {
+			inventoryWorkloads.WithLabelValues(w.Kind).Inc()
+		}
+	}
+}
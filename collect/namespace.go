@@ -4,31 +4,235 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectNamespaces(cs *ck.Clientset, i *inventory.Inventory) error {
-	nl := make([]*inventory.Namespace, 0)
-	namespaces, err := cs.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
-	if err != nil {
+func init() {
+	Register(WithLink(NewCollector("namespace", nil, collectNamespaces), linkNamespaces))
+}
+
+// Namespace extends the namespace of the shared model with the limits set for
+// the namespace and how much of them is used
+type Namespace struct {
+	inventory.Namespace
+	ResourceQuotas []ResourceQuota
+	LimitRanges    []LimitRange
+	// Usage sums the resources of the pods in the namespace
+	Usage       NamespaceUsage
+	PodSecurity PodSecurity
+}
+
+// PodSecurity is the Pod Security Standards levels set by the
+// pod-security.kubernetes.io labels of a namespace. Modes without a label are
+// left empty and follow the configuration of the admission controller.
+type PodSecurity struct {
+	Enforce        string `json:",omitempty"`
+	EnforceVersion string `json:",omitempty"`
+	Audit          string `json:",omitempty"`
+	AuditVersion   string `json:",omitempty"`
+	Warn           string `json:",omitempty"`
+	WarnVersion    string `json:",omitempty"`
+}
+
+type ResourceQuota struct {
+	Name   string
+	Scopes []string `json:",omitempty"`
+	Hard   map[string]string
+	Used   map[string]string
+}
+
+type LimitRange struct {
+	Name   string
+	Limits []LimitRangeItem
+}
+
+type LimitRangeItem struct {
+	// Type is Container, Pod or PersistentVolumeClaim
+	Type                 string
+	Max                  map[string]string `json:",omitempty"`
+	Min                  map[string]string `json:",omitempty"`
+	Default              map[string]string `json:",omitempty"`
+	DefaultRequest       map[string]string `json:",omitempty"`
+	MaxLimitRequestRatio map[string]string `json:",omitempty"`
+}
+
+// NamespaceUsage is the resources of the pods in a namespace which are not
+// done, in millicores and bytes. Pods are accounted as by the scheduler,
+// i.e. by the larger of their containers summed and their largest init
+// container.
+type NamespaceUsage struct {
+	Pods           int
+	RequestsCPU    int64
+	LimitsCPU      int64
+	RequestsMemory int64
+	LimitsMemory   int64
+	// PriorityClasses used by the pods
+	PriorityClasses []string
+}
+
+func collectNamespaces(ctx context.Context, cl *Clients, i *Inventory) error {
+	nl := make([]*Namespace, 0)
+	namespaces := &v1.NamespaceList{}
+	if err := cl.Client.List(ctx, namespaces); err != nil {
 		return fmt.Errorf("getting namespaces: %v", err)
 	}
-	var errs []error
+	quotas, quotasErr := collectResourceQuotas(ctx, cl)
+	limits, limitsErr := collectLimitRanges(ctx, cl)
 	for _, o := range namespaces.Items {
-		ns, err := collectNamespace(o)
-		errs = append(errs, err)
+		ns := collectNamespace(o)
+		ns.ResourceQuotas = quotas[o.Name]
+		if ns.ResourceQuotas == nil {
+			ns.ResourceQuotas = make([]ResourceQuota, 0)
+		}
+		ns.LimitRanges = limits[o.Name]
+		if ns.LimitRanges == nil {
+			ns.LimitRanges = make([]LimitRange, 0)
+		}
 		nl = append(nl, ns)
 	}
 	i.Namespaces = nl
-	return errors.Join(errs...)
+	return errors.Join(quotasErr, limitsErr)
 }
 
-func collectNamespace(o v1.Namespace) (*inventory.Namespace, error) {
-	r := inventory.NewNamespace()
+func collectNamespace(o v1.Namespace) *Namespace {
+	r := &Namespace{Namespace: *inventory.NewNamespace()}
 	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
-	return r, nil
+	l := o.Labels
+	r.PodSecurity = PodSecurity{
+		Enforce:        l["pod-security.kubernetes.io/enforce"],
+		EnforceVersion: l["pod-security.kubernetes.io/enforce-version"],
+		Audit:          l["pod-security.kubernetes.io/audit"],
+		AuditVersion:   l["pod-security.kubernetes.io/audit-version"],
+		Warn:           l["pod-security.kubernetes.io/warn"],
+		WarnVersion:    l["pod-security.kubernetes.io/warn-version"],
+	}
+	return r
+}
+
+// collectResourceQuotas returns the resource quotas by namespace
+func collectResourceQuotas(ctx context.Context, cl *Clients) (map[string][]ResourceQuota, error) {
+	quotas := make(map[string][]ResourceQuota)
+	quotaList := &v1.ResourceQuotaList{}
+	if err := cl.Client.List(ctx, quotaList); err != nil {
+		return quotas, fmt.Errorf("getting ResourceQuotas: %v", err)
+	}
+	for _, o := range quotaList.Items {
+		q := ResourceQuota{
+			Name: o.Name,
+			Hard: resourceList(o.Status.Hard),
+			Used: resourceList(o.Status.Used),
+		}
+		if len(o.Status.Hard) == 0 {
+			// Not yet accounted by the quota controller
+			q.Hard = resourceList(o.Spec.Hard)
+		}
+		for _, s := range o.Spec.Scopes {
+			q.Scopes = append(q.Scopes, string(s))
+		}
+		quotas[o.Namespace] = append(quotas[o.Namespace], q)
+	}
+	return quotas, nil
+}
+
+// collectLimitRanges returns the limit ranges by namespace
+func collectLimitRanges(ctx context.Context, cl *Clients) (map[string][]LimitRange, error) {
+	limits := make(map[string][]LimitRange)
+	limitList := &v1.LimitRangeList{}
+	if err := cl.Client.List(ctx, limitList); err != nil {
+		return limits, fmt.Errorf("getting LimitRanges: %v", err)
+	}
+	for _, o := range limitList.Items {
+		l := LimitRange{Name: o.Name, Limits: make([]LimitRangeItem, 0, len(o.Spec.Limits))}
+		for _, item := range o.Spec.Limits {
+			l.Limits = append(l.Limits, LimitRangeItem{
+				Type:                 string(item.Type),
+				Max:                  resourceList(item.Max),
+				Min:                  resourceList(item.Min),
+				Default:              resourceList(item.Default),
+				DefaultRequest:       resourceList(item.DefaultRequest),
+				MaxLimitRequestRatio: resourceList(item.MaxLimitRequestRatio),
+			})
+		}
+		limits[o.Namespace] = append(limits[o.Namespace], l)
+	}
+	return limits, nil
+}
+
+func resourceList(l v1.ResourceList) map[string]string {
+	if len(l) == 0 {
+		return nil
+	}
+	ret := make(map[string]string, len(l))
+	for k, q := range l {
+		ret[string(k)] = q.String()
+	}
+	return ret
+}
+
+// linkNamespaces sums the resources of the pods in each namespace
+func linkNamespaces(i *Inventory) {
+	usage := make(map[string]*NamespaceUsage)
+	priorityClasses := make(map[string]map[string]bool)
+	for _, w := range i.Workloads {
+		if w == nil || w.Kind != "Pod" {
+			continue
+		}
+		spec, ok := w.Spec.(inventory.PodSpec)
+		if !ok {
+			continue
+		}
+		if status, ok := w.Status.(inventory.PodStatus); ok &&
+			(status.Phase == string(v1.PodSucceeded) || status.Phase == string(v1.PodFailed)) {
+			continue
+		}
+		u := usage[w.Namespace]
+		if u == nil {
+			u = &NamespaceUsage{}
+			usage[w.Namespace] = u
+			priorityClasses[w.Namespace] = make(map[string]bool)
+		}
+		u.Pods++
+		var containers, init inventory.ResourceRequirements
+		for _, c := range spec.Containers {
+			containers.RequestsCPU += c.Resources.RequestsCPU
+			containers.LimitsCPU += c.Resources.LimitsCPU
+			containers.RequestsMemory += c.Resources.RequestsMemory
+			containers.LimitsMemory += c.Resources.LimitsMemory
+		}
+		for _, c := range spec.InitContainers {
+			if c.RestartPolicy != nil && *c.RestartPolicy == string(v1.ContainerRestartPolicyAlways) {
+				// Sidecars run next to the containers
+				containers.RequestsCPU += c.Resources.RequestsCPU
+				containers.LimitsCPU += c.Resources.LimitsCPU
+				containers.RequestsMemory += c.Resources.RequestsMemory
+				containers.LimitsMemory += c.Resources.LimitsMemory
+				continue
+			}
+			init.RequestsCPU = max(init.RequestsCPU, c.Resources.RequestsCPU)
+			init.LimitsCPU = max(init.LimitsCPU, c.Resources.LimitsCPU)
+			init.RequestsMemory = max(init.RequestsMemory, c.Resources.RequestsMemory)
+			init.LimitsMemory = max(init.LimitsMemory, c.Resources.LimitsMemory)
+		}
+		u.RequestsCPU += max(containers.RequestsCPU, init.RequestsCPU)
+		u.LimitsCPU += max(containers.LimitsCPU, init.LimitsCPU)
+		u.RequestsMemory += max(containers.RequestsMemory, init.RequestsMemory)
+		u.LimitsMemory += max(containers.LimitsMemory, init.LimitsMemory)
+		if spec.PriorityClassName != "" {
+			priorityClasses[w.Namespace][spec.PriorityClassName] = true
+		}
+	}
+	for _, ns := range i.Namespaces {
+		ns.Usage = NamespaceUsage{PriorityClasses: make([]string, 0)}
+		if u := usage[ns.Name]; u != nil {
+			ns.Usage = *u
+			ns.Usage.PriorityClasses = make([]string, 0, len(priorityClasses[ns.Name]))
+			for c := range priorityClasses[ns.Name] {
+				ns.Usage.PriorityClasses = append(ns.Usage.PriorityClasses, c)
+			}
+			sort.Strings(ns.Usage.PriorityClasses)
+		}
+	}
 }
@@ -0,0 +1,109 @@
+package collect
+
+import (
+	"reflect"
+	"testing"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+)
+
+func TestLinkNamespacesUsage(t *testing.T) {
+	always := "Always"
+	container := func(cpu, memory int64) inventory.Container {
+		return inventory.Container{Resources: inventory.ResourceRequirements{
+			RequestsCPU: cpu, LimitsCPU: 2 * cpu, RequestsMemory: memory, LimitsMemory: 2 * memory,
+		}}
+	}
+	sidecar := func(cpu, memory int64) inventory.Container {
+		c := container(cpu, memory)
+		c.RestartPolicy = &always
+		return c
+	}
+	tests := []struct {
+		name  string
+		specs []inventory.PodSpec
+		phase string
+		want  NamespaceUsage
+	}{
+		{
+			name: "no pods",
+			want: NamespaceUsage{PriorityClasses: []string{}},
+		},
+		{
+			name: "containers are summed",
+			specs: []inventory.PodSpec{
+				{Containers: []inventory.Container{container(100, 10), container(200, 20)}},
+				{Containers: []inventory.Container{container(300, 30)}},
+			},
+			want: NamespaceUsage{Pods: 2, RequestsCPU: 600, LimitsCPU: 1200, RequestsMemory: 60, LimitsMemory: 120, PriorityClasses: []string{}},
+		},
+		{
+			name: "largest init container counts if larger",
+			specs: []inventory.PodSpec{
+				{
+					InitContainers: []inventory.Container{container(500, 5), container(400, 4)},
+					Containers:     []inventory.Container{container(100, 10)},
+				},
+			},
+			want: NamespaceUsage{Pods: 1, RequestsCPU: 500, LimitsCPU: 1000, RequestsMemory: 10, LimitsMemory: 20, PriorityClasses: []string{}},
+		},
+		{
+			name: "sidecars are added to the containers",
+			specs: []inventory.PodSpec{
+				{
+					InitContainers: []inventory.Container{sidecar(50, 5), container(120, 1)},
+					Containers:     []inventory.Container{container(100, 10)},
+				},
+			},
+			want: NamespaceUsage{Pods: 1, RequestsCPU: 150, LimitsCPU: 300, RequestsMemory: 15, LimitsMemory: 30, PriorityClasses: []string{}},
+		},
+		{
+			name: "priority classes are listed once",
+			specs: []inventory.PodSpec{
+				{PriorityClassName: "high"},
+				{PriorityClassName: "default"},
+				{PriorityClassName: "high"},
+				{},
+			},
+			want: NamespaceUsage{Pods: 4, PriorityClasses: []string{"default", "high"}},
+		},
+		{
+			name:  "finished pods are skipped",
+			specs: []inventory.PodSpec{{Containers: []inventory.Container{container(100, 10)}}},
+			phase: "Succeeded",
+			want:  NamespaceUsage{PriorityClasses: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInventory()
+			ns := &Namespace{Namespace: *inventory.NewNamespace()}
+			ns.Name = "x"
+			other := &Namespace{Namespace: *inventory.NewNamespace()}
+			other.Name = "y"
+			i.Namespaces = []*Namespace{ns, other}
+			for _, spec := range tt.specs {
+				i.Workloads = append(i.Workloads, &inventory.Workload{
+					TypeMeta:   inventory.TypeMeta{Kind: "Pod"},
+					ObjectMeta: inventory.ObjectMeta{Namespace: "x"},
+					Spec:       spec,
+					Status:     inventory.PodStatus{Phase: tt.phase},
+				})
+			}
+			// Workloads other than pods are not counted
+			i.Workloads = append(i.Workloads, &inventory.Workload{
+				TypeMeta:   inventory.TypeMeta{Kind: "Deployment"},
+				ObjectMeta: inventory.ObjectMeta{Namespace: "x"},
+				Spec:       inventory.PodSpec{Containers: []inventory.Container{container(1000, 1000)}},
+			})
+
+			linkNamespaces(i)
+			if !reflect.DeepEqual(ns.Usage, tt.want) {
+				t.Errorf("got %+v, want %+v", ns.Usage, tt.want)
+			}
+			if want := (NamespaceUsage{PriorityClasses: []string{}}); !reflect.DeepEqual(other.Usage, want) {
+				t.Errorf("other namespace: got %+v", other.Usage)
+			}
+		})
+	}
+}
@@ -7,14 +7,16 @@ import (
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/networking/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectNetworkPolicies(cs *ck.Clientset, i *inventory.Inventory) error {
+func init() {
+	Register(NewCollector("network_policy", nil, collectNetworkPolicies))
+}
+
+func collectNetworkPolicies(ctx context.Context, cl *Clients, i *Inventory) error {
 	npl := make([]*inventory.NetworkPolicy, 0)
-	networkPolicies, err := cs.NetworkingV1().NetworkPolicies("").List(context.Background(), metav1.ListOptions{})
-	if err != nil {
+	networkPolicies := &v1.NetworkPolicyList{}
+	if err := cl.Client.List(ctx, networkPolicies); err != nil {
 		return fmt.Errorf("getting network policies: %v", err)
 	}
 	var errs []error
@@ -9,14 +9,16 @@ import (
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectNodes(cs *ck.Clientset, i *inventory.Inventory) error {
+func init() {
+	Register(NewCollector("node", nil, collectNodes))
+}
+
+func collectNodes(ctx context.Context, cl *Clients, i *Inventory) error {
 	nl := make([]*inventory.Node, 0)
-	nodes, err := cs.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
+	nodes := &v1.NodeList{}
+	if err := cl.Client.List(ctx, nodes); err != nil {
 		return fmt.Errorf("getting nodes: %v", err)
 	}
 	var errs []error
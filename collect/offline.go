@@ -0,0 +1,130 @@
+package collect
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// offlineCollectors only read through the controller-runtime client and can
+// run against objects loaded from manifests
+var offlineCollectors = map[string]bool{
+	"admission":             true,
+	"autoscaling":           true,
+	"components":            true,
+	"csi":                   true,
+	"ingress":               true,
+	"namespace":             true,
+	"network_policy":        true,
+	"node":                  true,
+	"pod_disruption_budget": true,
+	"priority_class":        true,
+	"rbac":                  true,
+	"service":               true,
+	"storage":               true,
+	"workload":              true,
+}
+
+// CollectOffline builds the inventory from objects loaded from manifests
+// instead of from an API server. Owners are resolved against the loaded
+// objects.
+func (c *InventoryCollection) CollectOffline(ctx context.Context, objs []*unstructured.Unstructured) *Inventory {
+	i := newInventory()
+
+	collectors := make([]Collector, 0, len(c.collectors))
+	for _, col := range c.collectors {
+		if offlineCollectors[col.Name()] {
+			collectors = append(collectors, col)
+		}
+	}
+	cl := offlineClients(objs)
+	for _, err := range c.runCollectors(ctx, cl, applicableCollectors(collectors, cl.APIResources), i) {
+		handleError(i, err)
+	}
+	c.publish(i)
+	observeInventory(i)
+	c.collected()
+	return i
+}
+
+// offlineClients returns clients reading from objs. Objects of kinds unknown
+// to the client-go scheme are only recorded as served API resources.
+func offlineClients(objs []*unstructured.Unstructured) *Clients {
+	resources := make(map[string]bool)
+	clusterScoped := make(map[schema.GroupVersionKind]bool)
+	typed := make(map[string]client.Object)
+	for _, u := range objs {
+		gvk := u.GroupVersionKind()
+		plural, _ := meta.UnsafeGuessKindToResource(gvk)
+		resources[gvk.GroupVersion().String()+"/"+plural.Resource] = true
+		if u.GetNamespace() == "" {
+			clusterScoped[gvk] = true
+		}
+
+		o, err := scheme.Scheme.New(gvk)
+		if err != nil {
+			log.Debug().Str("kind", gvk.String()).Msg("skipping object of unknown kind")
+			continue
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, o); err != nil {
+			log.Warn().Err(err).Str("kind", gvk.String()).Str("name", u.GetName()).Msg("converting object")
+			continue
+		}
+		// Later objects replace earlier ones, e.g. when a dump holds more than one copy
+		typed[strings.Join([]string{gvk.String(), u.GetNamespace(), u.GetName()}, "/")] = o.(client.Object)
+	}
+
+	l := make([]client.Object, 0, len(typed))
+	for _, o := range typed {
+		l = append(l, o)
+	}
+	log.Info().Int("objects", len(l)).Int("resources", len(resources)).Msg("loaded objects")
+	kc := fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithRESTMapper(offlineRESTMapper(clusterScoped)).
+		WithObjects(l...).
+		Build()
+	return &Clients{
+		Client:       offlineClient{kc},
+		APIResources: resources,
+	}
+}
+
+// offlineRESTMapper maps all kinds in the client-go scheme. Kinds are taken to
+// be namespaced unless they are in clusterScoped.
+func offlineRESTMapper(clusterScoped map[schema.GroupVersionKind]bool) meta.RESTMapper {
+	m := meta.NewDefaultRESTMapper(nil)
+	for gvk := range scheme.Scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal || strings.HasSuffix(gvk.Kind, "List") {
+			continue
+		}
+		scope := meta.RESTScopeNamespace
+		if clusterScoped[gvk] {
+			scope = meta.RESTScopeRoot
+		}
+		m.Add(gvk, scope)
+	}
+	return m
+}
+
+// offlineClient ignores the namespace when getting cluster scoped objects,
+// as the API server does. Owner references to e.g. nodes are resolved with
+// the namespace of the owned object.
+type offlineClient struct {
+	client.Client
+}
+
+func (c offlineClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if namespaced, err := c.IsObjectNamespaced(obj); err == nil && !namespaced {
+		key.Namespace = ""
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
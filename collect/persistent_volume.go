@@ -6,17 +6,14 @@ import (
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 	"k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectPVs(cs *ck.Clientset) ([]*inventory.PersistentVolume, error) {
+func collectPVs(ctx context.Context, kc client.Client) ([]*inventory.PersistentVolume, error) {
 	pvs := make([]*inventory.PersistentVolume, 0)
-	pvList, err := cs.CoreV1().
-		PersistentVolumes().
-		List(context.Background(), metav1.ListOptions{})
-	if err != nil {
+	pvList := &v1.PersistentVolumeList{}
+	if err := kc.List(ctx, pvList); err != nil {
 		return nil, fmt.Errorf("getting PersistentVolumes: %v", err)
 	}
 	for _, o := range pvList.Items {
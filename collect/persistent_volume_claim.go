@@ -0,0 +1,218 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/apis/core/v1/helper"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Reasons storage is reclaimable
+const (
+	// The claim is not mounted by any pod
+	reclaimNotMounted = "NotMounted"
+	// The volume bound to the claim is gone
+	reclaimLost = "Lost"
+	// The claim of the volume is deleted but the volume is retained
+	reclaimReleased = "Released"
+	// The volume is not bound to a claim
+	reclaimAvailable = "Available"
+	// Automatic reclamation of the volume failed
+	reclaimFailed = "Failed"
+)
+
+type PersistentVolumeClaim struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 PersistentVolumeClaimSpec   `json:"spec"`
+	Status               PersistentVolumeClaimStatus `json:"status"`
+	// Pods mounting the claim
+	Pods []string `json:",omitempty"`
+	// Workloads owning the pods mounting the claim
+	Workloads []*inventory.RootOwner `json:",omitempty"`
+}
+
+type PersistentVolumeClaimSpec struct {
+	// Requested storage in bytes
+	Requested        int64
+	AccessModes      string
+	StorageClassName string
+	VolumeMode       string
+	VolumeName       string
+}
+
+type PersistentVolumeClaimStatus struct {
+	Phase string
+	// Capacity of the bound volume in bytes
+	Capacity int64
+}
+
+// ReclaimableStorage is a claim or volume which is likely no longer used
+type ReclaimableStorage struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Reason               string
+	StorageClassName     string `json:",omitempty"`
+	// Capacity in bytes
+	Capacity int64
+}
+
+func NewPersistentVolumeClaim() *PersistentVolumeClaim {
+	return &PersistentVolumeClaim{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "PersistentVolumeClaim",
+			APIGroup:     "core",
+			APIVersion:   "v1",
+			ResourceType: "persistentvolumeclaims",
+		},
+	}
+}
+
+func collectPVCs(ctx context.Context, kc client.Client) ([]*PersistentVolumeClaim, error) {
+	pvcs := make([]*PersistentVolumeClaim, 0)
+	pvcList := &v1.PersistentVolumeClaimList{}
+	if err := kc.List(ctx, pvcList); err != nil {
+		return nil, fmt.Errorf("getting PersistentVolumeClaims: %v", err)
+	}
+	for _, o := range pvcList.Items {
+		pvcs = append(pvcs, collectPVC(o))
+	}
+	return pvcs, nil
+}
+
+func collectPVC(o v1.PersistentVolumeClaim) *PersistentVolumeClaim {
+	r := NewPersistentVolumeClaim()
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+
+	r.Spec = PersistentVolumeClaimSpec{
+		Requested:   o.Spec.Resources.Requests.Storage().Value(),
+		AccessModes: helper.GetAccessModesAsString(o.Spec.AccessModes),
+		VolumeName:  o.Spec.VolumeName,
+	}
+	if o.Spec.StorageClassName != nil {
+		r.Spec.StorageClassName = *o.Spec.StorageClassName
+	}
+	if o.Spec.VolumeMode != nil {
+		r.Spec.VolumeMode = string(*o.Spec.VolumeMode)
+	}
+
+	r.Status.Phase = string(o.Status.Phase)
+	if o.Status.Capacity != nil {
+		r.Status.Capacity = o.Status.Capacity.Storage().Value()
+	}
+	return r
+}
+
+// podClaims returns the names of the claims mounted by a pod
+func podClaims(o v1.Pod) []string {
+	var claims []string
+	for _, v := range o.Spec.Volumes {
+		switch {
+		case v.PersistentVolumeClaim != nil:
+			claims = append(claims, v.PersistentVolumeClaim.ClaimName)
+		case v.Ephemeral != nil:
+			// Generic ephemeral volumes are backed by a claim named after the
+			// pod and the volume
+			claims = append(claims, o.Name+"-"+v.Name)
+		}
+	}
+	return claims
+}
+
+// reclaimableStorage lists claims not mounted by any pod or bound to a lost
+// volume and volumes which are released, unbound or failed
+func reclaimableStorage(pvcs []*PersistentVolumeClaim, pvs []*inventory.PersistentVolume) []*ReclaimableStorage {
+	ret := make([]*ReclaimableStorage, 0)
+	for _, c := range pvcs {
+		reason := ""
+		switch {
+		case c.Status.Phase == string(v1.ClaimLost):
+			reason = reclaimLost
+		case len(c.Pods) == 0:
+			reason = reclaimNotMounted
+		default:
+			continue
+		}
+		capacity := c.Status.Capacity
+		if capacity == 0 {
+			capacity = c.Spec.Requested
+		}
+		ret = append(ret, &ReclaimableStorage{
+			TypeMeta:         c.TypeMeta,
+			ObjectMeta:       c.ObjectMeta,
+			Reason:           reason,
+			StorageClassName: c.Spec.StorageClassName,
+			Capacity:         capacity,
+		})
+	}
+	for _, pv := range pvs {
+		reason := ""
+		switch pv.Status.Phase {
+		case string(v1.VolumeReleased):
+			reason = reclaimReleased
+		case string(v1.VolumeAvailable):
+			reason = reclaimAvailable
+		case string(v1.VolumeFailed):
+			reason = reclaimFailed
+		default:
+			continue
+		}
+		ret = append(ret, &ReclaimableStorage{
+			TypeMeta:         pv.TypeMeta,
+			ObjectMeta:       pv.ObjectMeta,
+			Reason:           reason,
+			StorageClassName: pv.Spec.StorageClassName,
+			Capacity:         pv.Spec.Capacity,
+		})
+	}
+	return ret
+}
+
+// linkPVCs links claims and the pods mounting them and the workloads owning
+// those pods to each other, and lists the storage which is likely no longer
+// used. Nothing is linked if the pods could not be listed, as every claim
+// would seem unused.
+func linkPVCs(i *Inventory) {
+	if i.podClaims == nil {
+		return
+	}
+	mounts := make(map[string][]string)
+	owners := make(map[string]inventory.RootOwner)
+	for _, w := range i.Workloads {
+		if w == nil || w.Kind != "Pod" {
+			continue
+		}
+		for _, claim := range i.podClaims[w.Namespace+"/"+w.Name] {
+			key := w.Namespace + "/" + claim
+			mounts[key] = append(mounts[key], w.Name)
+		}
+		owner := asRootOwner(w)
+		if w.RootOwner != nil {
+			owner = *w.RootOwner
+		}
+		owners[w.Namespace+"/"+w.Name] = owner
+	}
+	for _, c := range i.Storage.PersistentVolumeClaims {
+		c.Pods = mounts[c.Namespace+"/"+c.Name]
+		sort.Strings(c.Pods)
+		c.Workloads = nil
+		seen := make(map[inventory.RootOwner]bool)
+		for _, p := range c.Pods {
+			owner, found := owners[c.Namespace+"/"+p]
+			if !found || seen[owner] {
+				continue
+			}
+			seen[owner] = true
+			c.Workloads = append(c.Workloads, &owner)
+			l := i.workloadLinks(owner)
+			l.PersistentVolumeClaims = append(l.PersistentVolumeClaims, Reference{Kind: c.Kind, Namespace: c.Namespace, Name: c.Name})
+		}
+	}
+	if i.Storage.PersistentVolumeClaims != nil {
+		i.Storage.Reclaimable = reclaimableStorage(i.Storage.PersistentVolumeClaims, i.Storage.PersistentVolumes)
+	}
+}
@@ -0,0 +1,136 @@
+package collect
+
+import (
+	"reflect"
+	"testing"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+)
+
+func testPVC(namespace, name, phase string) *PersistentVolumeClaim {
+	c := NewPersistentVolumeClaim()
+	c.Name, c.Namespace = name, namespace
+	c.Status.Phase = phase
+	c.Spec.Requested = 1024
+	return c
+}
+
+func TestLinkPVCs(t *testing.T) {
+	owner := &inventory.RootOwner{Kind: "StatefulSet", APIGroup: "apps", APIVersion: "v1", Name: "db", Namespace: "a"}
+	pod := func(name string, owner *inventory.RootOwner) *inventory.Workload {
+		w := inventory.NewPod()
+		w.Name, w.Namespace, w.RootOwner = name, "a", owner
+		return w
+	}
+
+	i := NewInventory()
+	i.Workloads = []*inventory.Workload{pod("db-0", owner), pod("db-1", owner), pod("tool", nil)}
+	i.podClaims = map[string][]string{
+		"a/db-0": {"data-db-0", "shared"},
+		"a/db-1": {"data-db-1", "shared"},
+		"a/tool": {"shared"},
+	}
+	shared, data0, unused, other := testPVC("a", "shared", "Bound"), testPVC("a", "data-db-0", "Bound"), testPVC("a", "unused", "Bound"), testPVC("b", "shared", "Bound")
+	i.Storage.PersistentVolumeClaims = []*PersistentVolumeClaim{shared, data0, unused, other}
+
+	linkPVCs(i)
+
+	tests := []struct {
+		claim     *PersistentVolumeClaim
+		pods      []string
+		workloads []*inventory.RootOwner
+	}{
+		{claim: shared, pods: []string{"db-0", "db-1", "tool"}, workloads: []*inventory.RootOwner{owner, {Kind: "Pod", APIVersion: "v1", Name: "tool", Namespace: "a"}}},
+		{claim: data0, pods: []string{"db-0"}, workloads: []*inventory.RootOwner{owner}},
+		{claim: unused},
+		{claim: other},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.claim.Pods, tt.pods) {
+			t.Errorf("%s/%s pods: got %v, want %v", tt.claim.Namespace, tt.claim.Name, tt.claim.Pods, tt.pods)
+		}
+		if !reflect.DeepEqual(tt.claim.Workloads, tt.workloads) {
+			t.Errorf("%s/%s workloads: got %+v, want %+v", tt.claim.Namespace, tt.claim.Name, tt.claim.Workloads, tt.workloads)
+		}
+	}
+
+	links := make(map[Reference][]string)
+	for _, l := range i.WorkloadLinks {
+		for _, c := range l.PersistentVolumeClaims {
+			if c.Kind != "PersistentVolumeClaim" || c.Namespace != "a" {
+				t.Errorf("%+v: got claim %+v", l.Workload, c)
+			}
+			links[l.Workload] = append(links[l.Workload], c.Name)
+		}
+	}
+	want := map[Reference][]string{
+		{APIGroup: "apps", Kind: "StatefulSet", Namespace: "a", Name: "db"}: {"shared", "data-db-0"},
+		{Kind: "Pod", Namespace: "a", Name: "tool"}:                         {"shared"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("workload links: got %v, want %v", links, want)
+	}
+
+	var reclaimable []string
+	for _, r := range i.Storage.Reclaimable {
+		reclaimable = append(reclaimable, r.Namespace+"/"+r.Name)
+	}
+	if want := []string{"a/unused", "b/shared"}; !reflect.DeepEqual(reclaimable, want) {
+		t.Errorf("reclaimable: got %v, want %v", reclaimable, want)
+	}
+}
+
+func TestLinkPVCsWithoutPods(t *testing.T) {
+	i := NewInventory()
+	c := testPVC("a", "data", "Bound")
+	i.Storage.PersistentVolumeClaims = []*PersistentVolumeClaim{c}
+	linkPVCs(i)
+	if c.Pods != nil || i.Storage.Reclaimable != nil || i.WorkloadLinks != nil {
+		t.Errorf("linked without pods: %+v %+v", c, i.Storage.Reclaimable)
+	}
+}
+
+func TestReclaimableStorage(t *testing.T) {
+	pv := func(name, phase string) *inventory.PersistentVolume {
+		v := inventory.NewPersistentVolume()
+		v.Name = name
+		v.Status.Phase = phase
+		v.Spec.StorageClassName = "fast"
+		v.Spec.Capacity = 2048
+		return v
+	}
+	mounted := testPVC("a", "mounted", "Bound")
+	mounted.Pods = []string{"p"}
+	lost := testPVC("a", "lost", "Lost")
+	lost.Pods = []string{"p"}
+	bound := testPVC("a", "bound", "Bound")
+	bound.Status.Capacity = 4096
+	pending := testPVC("a", "pending", "Pending")
+
+	got := reclaimableStorage(
+		[]*PersistentVolumeClaim{mounted, lost, bound, pending},
+		[]*inventory.PersistentVolume{pv("pv-bound", "Bound"), pv("pv-released", "Released"), pv("pv-available", "Available"), pv("pv-failed", "Failed"), pv("pv-pending", "Pending")},
+	)
+	type reclaimable struct {
+		name, reason string
+		capacity     int64
+	}
+	want := []reclaimable{
+		{"lost", reclaimLost, 1024},
+		{"bound", reclaimNotMounted, 4096},
+		{"pending", reclaimNotMounted, 1024},
+		{"pv-released", reclaimReleased, 2048},
+		{"pv-available", reclaimAvailable, 2048},
+		{"pv-failed", reclaimFailed, 2048},
+	}
+	var l []reclaimable
+	for _, r := range got {
+		l = append(l, reclaimable{r.Name, r.Reason, r.Capacity})
+	}
+	if !reflect.DeepEqual(l, want) {
+		t.Errorf("got %v, want %v", l, want)
+	}
+	if got[0].Kind != "PersistentVolumeClaim" || got[len(got)-1].StorageClassName != "fast" {
+		t.Errorf("got %+v and %+v", got[0], got[len(got)-1])
+	}
+}
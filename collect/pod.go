@@ -8,37 +8,43 @@ import (
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectPods(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, []*inventory.Workload, error) {
+// collectPods returns the pods, their owners and the claims mounted by each
+// pod, keyed by namespace and pod name. The claims are nil if the pods could
+// not be listed.
+func collectPods(ctx context.Context, kc client.Client) ([]*inventory.Workload, []*inventory.Workload, map[string][]string, error) {
 	pods := []*inventory.Workload{}
 	owners := []*inventory.Workload{}
-	options := metav1.ListOptions{Limit: 500}
+	claims := make(map[string][]string)
+	options := &client.ListOptions{Limit: 500}
 	var errs []error
 	for {
-		podList, err := cs.CoreV1().
-			Pods("").
-			List(ctx, options)
+		podList := &v1.PodList{}
+		err := kc.List(ctx, podList, options)
 		if err != nil && !k8serrors.IsNotFound(err) {
 			errs = append(errs, fmt.Errorf("getting Pods: %v", err))
+			// Some pods are missing, so the claims mounted are not known
+			claims = nil
 		}
 		for _, o := range podList.Items {
-			pod, owner, err := collectPod(ctx, client, o)
+			pod, owner, err := collectPod(ctx, kc, o)
 			errs = append(errs, err)
 			pods = append(pods, pod)
 			if owner != nil {
 				owners = append(owners, owner)
 			}
+			if c := podClaims(o); len(c) > 0 && claims != nil {
+				claims[o.Namespace+"/"+o.Name] = c
+			}
 		}
 		if podList.Continue == "" {
 			break
 		}
 		options.Continue = podList.Continue
 	}
-	return pods, owners, errors.Join(errs...)
+	return pods, owners, claims, errors.Join(errs...)
 }
 
 func collectPod(ctx context.Context, client client.Client, o v1.Pod) (*inventory.Workload, *inventory.Workload, error) {
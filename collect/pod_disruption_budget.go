@@ -0,0 +1,107 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func init() {
+	Register(WithLink(NewCollector("pod_disruption_budget", []string{"policy/v1/poddisruptionbudgets"}, collectPodDisruptionBudgets), linkPodDisruptionBudgets))
+}
+
+type PodDisruptionBudget struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 PodDisruptionBudgetSpec   `json:"spec"`
+	Status               PodDisruptionBudgetStatus `json:"status"`
+	// Workloads owning the pods selected by the budget
+	Workloads []*inventory.RootOwner `json:",omitempty"`
+
+	selector *metav1.LabelSelector
+}
+
+type PodDisruptionBudgetSpec struct {
+	// MinAvailable and MaxUnavailable are numbers or percentages
+	MinAvailable               string `json:",omitempty"`
+	MaxUnavailable             string `json:",omitempty"`
+	Selector                   *inventory.LabelSelector
+	UnhealthyPodEvictionPolicy string `json:",omitempty"`
+}
+
+type PodDisruptionBudgetStatus struct {
+	CurrentHealthy     int32
+	DesiredHealthy     int32
+	ExpectedPods       int32
+	DisruptionsAllowed int32
+	Conditions         []Condition
+}
+
+func NewPodDisruptionBudget() *PodDisruptionBudget {
+	return &PodDisruptionBudget{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "PodDisruptionBudget",
+			APIGroup:     policyv1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "poddisruptionbudgets",
+		},
+	}
+}
+
+func collectPodDisruptionBudgets(ctx context.Context, cl *Clients, i *Inventory) error {
+	pdbs := make([]*PodDisruptionBudget, 0)
+	pdbList := &policyv1.PodDisruptionBudgetList{}
+	if err := cl.Client.List(ctx, pdbList); err != nil {
+		return fmt.Errorf("getting PodDisruptionBudgets: %v", err)
+	}
+	for _, o := range pdbList.Items {
+		r := NewPodDisruptionBudget()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		if o.Spec.MinAvailable != nil {
+			r.Spec.MinAvailable = o.Spec.MinAvailable.String()
+		}
+		if o.Spec.MaxUnavailable != nil {
+			r.Spec.MaxUnavailable = o.Spec.MaxUnavailable.String()
+		}
+		r.Spec.Selector = labelSelector(o.Spec.Selector)
+		if o.Spec.UnhealthyPodEvictionPolicy != nil {
+			r.Spec.UnhealthyPodEvictionPolicy = string(*o.Spec.UnhealthyPodEvictionPolicy)
+		}
+		r.selector = o.Spec.Selector
+
+		r.Status = PodDisruptionBudgetStatus{
+			CurrentHealthy:     o.Status.CurrentHealthy,
+			DesiredHealthy:     o.Status.DesiredHealthy,
+			ExpectedPods:       o.Status.ExpectedPods,
+			DisruptionsAllowed: o.Status.DisruptionsAllowed,
+			Conditions:         conditions(o.Status.Conditions),
+		}
+		pdbs = append(pdbs, r)
+	}
+	i.Availability.PodDisruptionBudgets = pdbs
+	return nil
+}
+
+// linkPodDisruptionBudgets links budgets and the workloads owning the pods
+// they select to each other. An empty selector selects all pods in the
+// namespace.
+func linkPodDisruptionBudgets(i *Inventory) {
+	pods := podsByNamespace(i)
+	for _, b := range i.Availability.PodDisruptionBudgets {
+		if b.selector == nil {
+			continue
+		}
+		s, err := metav1.LabelSelectorAsSelector(b.selector)
+		if err != nil {
+			continue
+		}
+		b.Workloads = matchWorkloads(pods[b.Namespace], s)
+		for _, w := range b.Workloads {
+			l := i.workloadLinks(*w)
+			l.PodDisruptionBudgets = append(l.PodDisruptionBudgets, Reference{APIGroup: b.APIGroup, Kind: b.Kind, Namespace: b.Namespace, Name: b.Name})
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+)
+
+func init() {
+	Register(NewCollector("priority_class", nil, collectPriorityClasses))
+}
+
+type PriorityClass struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Value                int32
+	GlobalDefault        bool
+	PreemptionPolicy     string
+	Description          string `json:",omitempty"`
+}
+
+func NewPriorityClass() *PriorityClass {
+	return &PriorityClass{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "PriorityClass",
+			APIGroup:     schedulingv1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "priorityclasses",
+		},
+	}
+}
+
+func collectPriorityClasses(ctx context.Context, cl *Clients, i *Inventory) error {
+	classes := make([]*PriorityClass, 0)
+	classList := &schedulingv1.PriorityClassList{}
+	if err := cl.Client.List(ctx, classList); err != nil {
+		return fmt.Errorf("getting PriorityClasses: %v", err)
+	}
+	for _, o := range classList.Items {
+		r := NewPriorityClass()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Value = o.Value
+		r.GlobalDefault = o.GlobalDefault
+		r.PreemptionPolicy = "PreemptLowerPriority"
+		if o.PreemptionPolicy != nil {
+			r.PreemptionPolicy = string(*o.PreemptionPolicy)
+		}
+		r.Description = o.Description
+		classes = append(classes, r)
+	}
+	i.PriorityClasses = classes
+	return nil
+}
@@ -1,15 +1,27 @@
 package collect
 
 import (
+	"context"
+
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	"github.com/neticdk-k8s/k8s-inventory-client/kubernetes"
 	rmqapi "github.com/rabbitmq/cluster-operator/api/v1beta1"
 	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectRabbitMQClusters(cs *ck.Clientset) ([]*inventory.RabbitMQCluster, error) {
+func init() {
+	Register(NewCollector("rabbitmq", []string{"rabbitmq.com/v1beta1/rabbitmqclusters"}, collectRabbitMQ))
+}
+
+func collectRabbitMQ(ctx context.Context, cl *Clients, i *Inventory) error {
+	clusters, err := collectRabbitMQClusters(ctx, cl.Clientset)
+	i.CustomResources.RabbitMQ.Clusters = clusters
+	return err
+}
+
+func collectRabbitMQClusters(ctx context.Context, cs *ck.Clientset) ([]*inventory.RabbitMQCluster, error) {
 	rmqClusters := make([]*inventory.RabbitMQCluster, 0)
-	res, found, err := kubernetes.GetK8SRESTResource(cs, "/apis/rabbitmq.com/v1beta1/rabbitmqclusters")
+	res, found, err := kubernetes.GetK8SRESTResource(ctx, cs, "/apis/rabbitmq.com/v1beta1/rabbitmqclusters")
 	if err != nil {
 		return nil, err
 	}
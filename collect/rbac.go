@@ -0,0 +1,340 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func init() {
+	Register(WithLink(NewCollector("rbac", nil, collectRBAC), linkServiceAccounts))
+}
+
+type ServiceAccount struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	// Workloads running pods as the service account
+	Workloads []*inventory.RootOwner `json:",omitempty"`
+	// Rules is the effective set of rules granted to the service account by
+	// bindings naming it. Rules granted to groups of service accounts are
+	// recorded once in GroupRules of RBAC.
+	Rules []EffectiveRule
+	// Wildcard is set if any of the rules, or of the rules granted to groups
+	// of the service account, use a wildcard
+	Wildcard bool
+	// ClusterAdmin is set if the service account, or a group of it, is
+	// granted every verb on every resource in the cluster
+	ClusterAdmin bool
+}
+
+// Role is a Role or a ClusterRole
+type Role struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Rules                []PolicyRule
+	// AggregationLabels are the label selectors of aggregated ClusterRoles
+	AggregationLabels []map[string]string `json:",omitempty"`
+	Wildcard          bool
+	// ClusterAdmin is set if the role grants every verb on every resource
+	ClusterAdmin bool
+}
+
+type PolicyRule struct {
+	Verbs           []string
+	APIGroups       []string `json:",omitempty"`
+	Resources       []string `json:",omitempty"`
+	ResourceNames   []string `json:",omitempty"`
+	NonResourceURLs []string `json:",omitempty"`
+	Wildcard        bool
+}
+
+// RoleBinding is a RoleBinding or a ClusterRoleBinding
+type RoleBinding struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	RoleRef              Reference
+	Subjects             []Reference
+}
+
+// EffectiveRule is a rule granted through a binding. Namespace is empty for
+// rules granted in all namespaces.
+type EffectiveRule struct {
+	PolicyRule
+	Namespace string `json:",omitempty"`
+	Role      Reference
+	Binding   Reference
+}
+
+// GroupRule is a rule granted to a group of service accounts, i.e. all of
+// them or those in a namespace
+type GroupRule struct {
+	EffectiveRule
+	Group string
+	// ServiceAccountNamespace is the namespace of the service accounts in
+	// the group, empty if the group has every service account
+	ServiceAccountNamespace string `json:",omitempty"`
+	// ClusterAdmin is set if the rule grants every verb on every resource
+	// in the cluster
+	ClusterAdmin bool
+}
+
+func NewServiceAccount() *ServiceAccount {
+	return &ServiceAccount{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "ServiceAccount",
+			APIGroup:     "core",
+			APIVersion:   "v1",
+			ResourceType: "serviceaccounts",
+		},
+	}
+}
+
+func NewRole(kind, resourceType string) *Role {
+	return &Role{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     rbacv1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: resourceType,
+		},
+	}
+}
+
+func NewRoleBinding(kind, resourceType string) *RoleBinding {
+	return &RoleBinding{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         kind,
+			APIGroup:     rbacv1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: resourceType,
+		},
+	}
+}
+
+func collectRBAC(ctx context.Context, cl *Clients, i *Inventory) error {
+	var errs []error
+	saList := &v1.ServiceAccountList{}
+	if err := cl.Client.List(ctx, saList); err != nil {
+		errs = append(errs, fmt.Errorf("getting ServiceAccounts: %v", err))
+	}
+	roleList := &rbacv1.RoleList{}
+	if err := cl.Client.List(ctx, roleList); err != nil {
+		errs = append(errs, fmt.Errorf("getting Roles: %v", err))
+	}
+	clusterRoleList := &rbacv1.ClusterRoleList{}
+	if err := cl.Client.List(ctx, clusterRoleList); err != nil {
+		errs = append(errs, fmt.Errorf("getting ClusterRoles: %v", err))
+	}
+	bindingList := &rbacv1.RoleBindingList{}
+	if err := cl.Client.List(ctx, bindingList); err != nil {
+		errs = append(errs, fmt.Errorf("getting RoleBindings: %v", err))
+	}
+	clusterBindingList := &rbacv1.ClusterRoleBindingList{}
+	if err := cl.Client.List(ctx, clusterBindingList); err != nil {
+		errs = append(errs, fmt.Errorf("getting ClusterRoleBindings: %v", err))
+	}
+
+	i.RBAC.ServiceAccounts = make([]*ServiceAccount, 0, len(saList.Items))
+	for _, o := range saList.Items {
+		r := NewServiceAccount()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		i.RBAC.ServiceAccounts = append(i.RBAC.ServiceAccounts, r)
+	}
+	i.RBAC.Roles = make([]*Role, 0, len(roleList.Items))
+	for _, o := range roleList.Items {
+		r := NewRole("Role", "roles")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		setRules(r, o.Rules)
+		i.RBAC.Roles = append(i.RBAC.Roles, r)
+	}
+	i.RBAC.ClusterRoles = make([]*Role, 0, len(clusterRoleList.Items))
+	for _, o := range clusterRoleList.Items {
+		r := NewRole("ClusterRole", "clusterroles")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		setRules(r, o.Rules)
+		if o.AggregationRule != nil {
+			for _, s := range o.AggregationRule.ClusterRoleSelectors {
+				r.AggregationLabels = append(r.AggregationLabels, s.MatchLabels)
+			}
+		}
+		i.RBAC.ClusterRoles = append(i.RBAC.ClusterRoles, r)
+	}
+	i.RBAC.RoleBindings = make([]*RoleBinding, 0, len(bindingList.Items))
+	for _, o := range bindingList.Items {
+		r := NewRoleBinding("RoleBinding", "rolebindings")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		setBinding(r, o.RoleRef, o.Subjects)
+		i.RBAC.RoleBindings = append(i.RBAC.RoleBindings, r)
+	}
+	i.RBAC.ClusterRoleBindings = make([]*RoleBinding, 0, len(clusterBindingList.Items))
+	for _, o := range clusterBindingList.Items {
+		r := NewRoleBinding("ClusterRoleBinding", "clusterrolebindings")
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		setBinding(r, o.RoleRef, o.Subjects)
+		i.RBAC.ClusterRoleBindings = append(i.RBAC.ClusterRoleBindings, r)
+	}
+	return errors.Join(errs...)
+}
+
+func setRules(r *Role, rules []rbacv1.PolicyRule) {
+	r.Rules = make([]PolicyRule, 0, len(rules))
+	for _, o := range rules {
+		rule := PolicyRule{
+			Verbs:           o.Verbs,
+			APIGroups:       o.APIGroups,
+			Resources:       o.Resources,
+			ResourceNames:   o.ResourceNames,
+			NonResourceURLs: o.NonResourceURLs,
+		}
+		rule.Wildcard = slices.Contains(o.Verbs, rbacv1.VerbAll) ||
+			slices.Contains(o.APIGroups, rbacv1.APIGroupAll) ||
+			slices.Contains(o.Resources, rbacv1.ResourceAll) ||
+			slices.Contains(o.NonResourceURLs, rbacv1.NonResourceAll)
+		r.Wildcard = r.Wildcard || rule.Wildcard
+		r.ClusterAdmin = r.ClusterAdmin || grantsAll(rule)
+		r.Rules = append(r.Rules, rule)
+	}
+}
+
+// grantsAll tells if rule grants every verb on every resource, as the
+// cluster-admin role does
+func grantsAll(rule PolicyRule) bool {
+	return slices.Contains(rule.Verbs, rbacv1.VerbAll) &&
+		slices.Contains(rule.APIGroups, rbacv1.APIGroupAll) &&
+		slices.Contains(rule.Resources, rbacv1.ResourceAll) &&
+		len(rule.ResourceNames) == 0
+}
+
+func setBinding(r *RoleBinding, ref rbacv1.RoleRef, subjects []rbacv1.Subject) {
+	r.RoleRef = Reference{APIGroup: ref.APIGroup, Kind: ref.Kind, Name: ref.Name}
+	if ref.Kind == "Role" {
+		r.RoleRef.Namespace = r.Namespace
+	}
+	r.Subjects = make([]Reference, 0, len(subjects))
+	for _, s := range subjects {
+		namespace := s.Namespace
+		if s.Kind == rbacv1.ServiceAccountKind && namespace == "" {
+			namespace = r.Namespace
+		}
+		r.Subjects = append(r.Subjects, Reference{APIGroup: s.APIGroup, Kind: s.Kind, Namespace: namespace, Name: s.Name})
+	}
+}
+
+// linkServiceAccounts resolves the rules granted to each service account and
+// to groups of service accounts, and the workloads running as each service
+// account
+func linkServiceAccounts(i *Inventory) {
+	i.RBAC.GroupRules = make([]GroupRule, 0)
+	accounts := make(map[string]*ServiceAccount)
+	for _, sa := range i.RBAC.ServiceAccounts {
+		sa.Workloads = nil
+		sa.Rules = make([]EffectiveRule, 0)
+		sa.Wildcard, sa.ClusterAdmin = false, false
+		accounts[sa.Namespace+"/"+sa.Name] = sa
+	}
+
+	seen := make(map[*ServiceAccount]map[inventory.RootOwner]bool)
+	for _, w := range i.Workloads {
+		if w == nil || w.Kind != "Pod" {
+			continue
+		}
+		spec, ok := w.Spec.(inventory.PodSpec)
+		if !ok {
+			continue
+		}
+		name := spec.ServiceAccountName
+		if name == "" {
+			name = "default"
+		}
+		sa, found := accounts[w.Namespace+"/"+name]
+		if !found {
+			continue
+		}
+		owner := inventory.RootOwner{Kind: w.Kind, APIVersion: w.APIVersion, Name: w.Name, Namespace: w.Namespace}
+		if w.RootOwner != nil {
+			owner = *w.RootOwner
+		}
+		if seen[sa] == nil {
+			seen[sa] = make(map[inventory.RootOwner]bool)
+		}
+		if !seen[sa][owner] {
+			seen[sa][owner] = true
+			o := owner
+			sa.Workloads = append(sa.Workloads, &o)
+		}
+	}
+
+	roles := make(map[Reference]*Role)
+	for _, r := range i.RBAC.Roles {
+		roles[Reference{Kind: r.Kind, Namespace: r.Namespace, Name: r.Name}] = r
+	}
+	for _, r := range i.RBAC.ClusterRoles {
+		roles[Reference{Kind: r.Kind, Name: r.Name}] = r
+	}
+	grant := func(b *RoleBinding, namespace string) {
+		role, found := roles[Reference{Kind: b.RoleRef.Kind, Namespace: b.RoleRef.Namespace, Name: b.RoleRef.Name}]
+		if !found {
+			return
+		}
+		binding := Reference{APIGroup: b.APIGroup, Kind: b.Kind, Namespace: b.Namespace, Name: b.Name}
+		for _, s := range b.Subjects {
+			switch s.Kind {
+			case rbacv1.ServiceAccountKind:
+				sa, found := accounts[s.Namespace+"/"+s.Name]
+				if !found {
+					continue
+				}
+				for _, rule := range role.Rules {
+					sa.Rules = append(sa.Rules, EffectiveRule{PolicyRule: rule, Namespace: namespace, Role: b.RoleRef, Binding: binding})
+					sa.Wildcard = sa.Wildcard || rule.Wildcard
+					sa.ClusterAdmin = sa.ClusterAdmin || (namespace == "" && grantsAll(rule))
+				}
+			case rbacv1.GroupKind:
+				saNamespace, found := serviceAccountGroup(s.Name)
+				if !found {
+					continue
+				}
+				for _, rule := range role.Rules {
+					clusterAdmin := namespace == "" && grantsAll(rule)
+					i.RBAC.GroupRules = append(i.RBAC.GroupRules, GroupRule{
+						EffectiveRule:           EffectiveRule{PolicyRule: rule, Namespace: namespace, Role: b.RoleRef, Binding: binding},
+						Group:                   s.Name,
+						ServiceAccountNamespace: saNamespace,
+						ClusterAdmin:            clusterAdmin,
+					})
+					if !rule.Wildcard && !clusterAdmin {
+						continue
+					}
+					for _, sa := range i.RBAC.ServiceAccounts {
+						if saNamespace == "" || sa.Namespace == saNamespace {
+							sa.Wildcard = sa.Wildcard || rule.Wildcard
+							sa.ClusterAdmin = sa.ClusterAdmin || clusterAdmin
+						}
+					}
+				}
+			}
+		}
+	}
+	for _, b := range i.RBAC.ClusterRoleBindings {
+		grant(b, "")
+	}
+	for _, b := range i.RBAC.RoleBindings {
+		grant(b, b.Namespace)
+	}
+}
+
+// serviceAccountGroup tells if group has service accounts and if so the
+// namespace they are in, which is empty for groups of all service accounts
+func serviceAccountGroup(group string) (namespace string, found bool) {
+	switch group {
+	case "system:authenticated", "system:serviceaccounts":
+		return "", true
+	}
+	return strings.CutPrefix(group, "system:serviceaccounts:")
+}
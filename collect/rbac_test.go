@@ -0,0 +1,143 @@
+package collect
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestLinkServiceAccountsRules(t *testing.T) {
+	clusterRole := func(name string, rules ...rbacv1.PolicyRule) *Role {
+		r := NewRole("ClusterRole", "clusterroles")
+		r.Name = name
+		setRules(r, rules)
+		return r
+	}
+	admin := clusterRole("cluster-admin", rbacv1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}})
+	view := clusterRole("view", rbacv1.PolicyRule{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}})
+
+	binding := func(kind, namespace, role string, subjects ...rbacv1.Subject) *RoleBinding {
+		resourceType := "clusterrolebindings"
+		if kind == "RoleBinding" {
+			resourceType = "rolebindings"
+		}
+		b := NewRoleBinding(kind, resourceType)
+		b.Name, b.Namespace = role, namespace
+		setBinding(b, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role}, subjects)
+		return b
+	}
+	sa := func(namespace, name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+	}
+	group := func(name string) rbacv1.Subject {
+		return rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: name}
+	}
+
+	type account struct {
+		rules                  int
+		wildcard, clusterAdmin bool
+	}
+	tests := []struct {
+		name       string
+		bindings   []*RoleBinding
+		a, b       account
+		groupRules int
+	}{
+		{
+			name: "no bindings",
+		},
+		{
+			name:     "cluster binding to a service account",
+			bindings: []*RoleBinding{binding("ClusterRoleBinding", "", "cluster-admin", sa("a", "app"))},
+			a:        account{rules: 1, wildcard: true, clusterAdmin: true},
+		},
+		{
+			name:     "namespaced binding of the cluster admin role",
+			bindings: []*RoleBinding{binding("RoleBinding", "b", "cluster-admin", sa("b", "app"))},
+			b:        account{rules: 1, wildcard: true},
+		},
+		{
+			name:     "namespaced binding without subject namespace",
+			bindings: []*RoleBinding{binding("RoleBinding", "a", "view", rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "app"})},
+			a:        account{rules: 1},
+		},
+		{
+			name:     "unknown service account",
+			bindings: []*RoleBinding{binding("ClusterRoleBinding", "", "cluster-admin", sa("c", "app"))},
+		},
+		{
+			name:       "cluster admin for all service accounts",
+			bindings:   []*RoleBinding{binding("ClusterRoleBinding", "", "cluster-admin", group("system:serviceaccounts"))},
+			a:          account{wildcard: true, clusterAdmin: true},
+			b:          account{wildcard: true, clusterAdmin: true},
+			groupRules: 1,
+		},
+		{
+			name:       "cluster admin for the authenticated",
+			bindings:   []*RoleBinding{binding("ClusterRoleBinding", "", "cluster-admin", group("system:authenticated"))},
+			a:          account{wildcard: true, clusterAdmin: true},
+			b:          account{wildcard: true, clusterAdmin: true},
+			groupRules: 1,
+		},
+		{
+			name:       "cluster admin for the service accounts in a namespace",
+			bindings:   []*RoleBinding{binding("ClusterRoleBinding", "", "cluster-admin", group("system:serviceaccounts:b"))},
+			b:          account{wildcard: true, clusterAdmin: true},
+			groupRules: 1,
+		},
+		{
+			name:       "namespaced binding to a group",
+			bindings:   []*RoleBinding{binding("RoleBinding", "a", "cluster-admin", group("system:serviceaccounts:a"))},
+			a:          account{wildcard: true},
+			groupRules: 1,
+		},
+		{
+			name:       "group rules are recorded once",
+			bindings:   []*RoleBinding{binding("ClusterRoleBinding", "", "view", group("system:serviceaccounts"), sa("a", "app"))},
+			a:          account{rules: 1},
+			groupRules: 1,
+		},
+		{
+			name:     "other groups",
+			bindings: []*RoleBinding{binding("ClusterRoleBinding", "", "cluster-admin", group("system:masters"), group("system:serviceaccounts-a"))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInventory()
+			a, b := NewServiceAccount(), NewServiceAccount()
+			a.Name, a.Namespace = "app", "a"
+			b.Name, b.Namespace = "app", "b"
+			i.RBAC.ServiceAccounts = []*ServiceAccount{a, b}
+			i.RBAC.ClusterRoles = []*Role{admin, view}
+			for _, b := range tt.bindings {
+				if b.Kind == "RoleBinding" {
+					i.RBAC.RoleBindings = append(i.RBAC.RoleBindings, b)
+				} else {
+					i.RBAC.ClusterRoleBindings = append(i.RBAC.ClusterRoleBindings, b)
+				}
+			}
+
+			// Linking twice gives the same result
+			linkServiceAccounts(i)
+			linkServiceAccounts(i)
+			for _, c := range []struct {
+				sa   *ServiceAccount
+				want account
+			}{{a, tt.a}, {b, tt.b}} {
+				got := account{rules: len(c.sa.Rules), wildcard: c.sa.Wildcard, clusterAdmin: c.sa.ClusterAdmin}
+				if got != c.want {
+					t.Errorf("%s: got %+v, want %+v", c.sa.Namespace, got, c.want)
+				}
+			}
+			if len(i.RBAC.GroupRules) != tt.groupRules {
+				t.Errorf("group rules: got %d, want %d", len(i.RBAC.GroupRules), tt.groupRules)
+			}
+			for _, r := range i.RBAC.GroupRules {
+				if want := r.Namespace == "" && r.Role.Name == "cluster-admin"; r.ClusterAdmin != want {
+					t.Errorf("%s: got cluster admin %v", r.Group, r.ClusterAdmin)
+				}
+			}
+		})
+	}
+}
@@ -7,23 +7,19 @@ import (
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/apps/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectReplicaSets(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectReplicaSets(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	rsets := make([]*inventory.Workload, 0)
 
-	replicaSetList, err := cs.AppsV1().
-		ReplicaSets("").
-		List(ctx, metav1.ListOptions{})
-	if err != nil {
+	replicaSetList := &v1.ReplicaSetList{}
+	if err := kc.List(ctx, replicaSetList); err != nil {
 		return nil, fmt.Errorf("getting ReplicaSets: %v", err)
 	}
 	var errs []error
 	for _, o := range replicaSetList.Items {
-		rset, err := collectReplicaSet(ctx, client, o)
+		rset, err := collectReplicaSet(ctx, kc, o)
 		errs = append(errs, err)
 		rsets = append(rsets, rset)
 	}
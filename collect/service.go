@@ -0,0 +1,211 @@
+package collect
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func init() {
+	Register(WithLink(NewCollector("service", nil, collectServices), linkServices))
+}
+
+type Service struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 ServiceSpec   `json:"spec"`
+	Status               ServiceStatus `json:"status"`
+	// Workloads owning the pods selected by the service
+	Workloads []*inventory.RootOwner `json:",omitempty"`
+}
+
+type ServiceSpec struct {
+	Type                     string
+	ClusterIP                string
+	ExternalIPs              []string
+	ExternalName             string
+	LoadBalancerSourceRanges []string
+	ExternalTrafficPolicy    string
+	Selector                 map[string]string
+	Ports                    []ServicePort
+}
+
+type ServicePort struct {
+	Name       string
+	Protocol   string
+	Port       int32
+	TargetPort string
+	NodePort   int32
+}
+
+type ServiceStatus struct {
+	LoadBalancer []LoadBalancerIngress
+	// Endpoints summarizes the EndpointSlices of the service
+	Endpoints EndpointsSummary
+}
+
+type LoadBalancerIngress struct {
+	IP       string
+	Hostname string
+}
+
+type EndpointsSummary struct {
+	Ready       int
+	NotReady    int
+	Terminating int
+}
+
+func NewService() *Service {
+	return &Service{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "Service",
+			APIGroup:     "core",
+			APIVersion:   "v1",
+			ResourceType: "services",
+		},
+	}
+}
+
+func collectServices(ctx context.Context, cl *Clients, i *Inventory) error {
+	services := make([]*Service, 0)
+	serviceList := &v1.ServiceList{}
+	if err := cl.Client.List(ctx, serviceList); err != nil {
+		return fmt.Errorf("getting Services: %v", err)
+	}
+
+	var endpoints map[string]EndpointsSummary
+	if cl.APIResources["discovery.k8s.io/v1/endpointslices"] {
+		var err error
+		if endpoints, err = collectEndpointSummaries(ctx, cl); err != nil {
+			return err
+		}
+	}
+	for _, o := range serviceList.Items {
+		s := collectService(o)
+		s.Status.Endpoints = endpoints[o.Namespace+"/"+o.Name]
+		services = append(services, s)
+	}
+	i.Networking.Services = services
+	return nil
+}
+
+func collectService(o v1.Service) *Service {
+	r := NewService()
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+
+	r.Spec = ServiceSpec{
+		Type:                     string(o.Spec.Type),
+		ClusterIP:                o.Spec.ClusterIP,
+		ExternalIPs:              o.Spec.ExternalIPs,
+		ExternalName:             o.Spec.ExternalName,
+		LoadBalancerSourceRanges: o.Spec.LoadBalancerSourceRanges,
+		ExternalTrafficPolicy:    string(o.Spec.ExternalTrafficPolicy),
+		Selector:                 o.Spec.Selector,
+		Ports:                    make([]ServicePort, 0, len(o.Spec.Ports)),
+	}
+	for _, p := range o.Spec.Ports {
+		r.Spec.Ports = append(r.Spec.Ports, ServicePort{
+			Name:       p.Name,
+			Protocol:   string(p.Protocol),
+			Port:       p.Port,
+			TargetPort: p.TargetPort.String(),
+			NodePort:   p.NodePort,
+		})
+	}
+	r.Status.LoadBalancer = make([]LoadBalancerIngress, 0, len(o.Status.LoadBalancer.Ingress))
+	for _, lb := range o.Status.LoadBalancer.Ingress {
+		r.Status.LoadBalancer = append(r.Status.LoadBalancer, LoadBalancerIngress{IP: lb.IP, Hostname: lb.Hostname})
+	}
+	return r
+}
+
+// collectEndpointSummaries counts the endpoints of each service, keyed by
+// namespace and name
+func collectEndpointSummaries(ctx context.Context, cl *Clients) (map[string]EndpointsSummary, error) {
+	summaries := make(map[string]EndpointsSummary)
+	sliceList := &discoveryv1.EndpointSliceList{}
+	if err := cl.Client.List(ctx, sliceList); err != nil {
+		return nil, fmt.Errorf("getting EndpointSlices: %v", err)
+	}
+	for _, o := range sliceList.Items {
+		service := o.Labels[discoveryv1.LabelServiceName]
+		if service == "" {
+			continue
+		}
+		key := o.Namespace + "/" + service
+		s := summaries[key]
+		for _, e := range o.Endpoints {
+			switch {
+			case e.Conditions.Terminating != nil && *e.Conditions.Terminating:
+				s.Terminating++
+			case e.Conditions.Ready == nil || *e.Conditions.Ready:
+				// A nil ready condition is to be read as ready
+				s.Ready++
+			default:
+				s.NotReady++
+			}
+		}
+		summaries[key] = s
+	}
+	return summaries, nil
+}
+
+func linkServices(i *Inventory) {
+	pods := podsByNamespace(i)
+	for _, s := range i.Networking.Services {
+		s.Workloads = selectWorkloads(pods[s.Namespace], s.Spec.Selector)
+	}
+}
+
+// podsByNamespace returns the pods of the inventory by namespace
+func podsByNamespace(i *Inventory) map[string][]*inventory.Workload {
+	pods := make(map[string][]*inventory.Workload)
+	for _, w := range i.Workloads {
+		if w != nil && w.Kind == "Pod" {
+			pods[w.Namespace] = append(pods[w.Namespace], w)
+		}
+	}
+	return pods
+}
+
+// selectWorkloads returns the root owners of the pods matching selector.
+// Pods without an owner are returned themselves. An empty selector selects
+// nothing, as for services.
+func selectWorkloads(pods []*inventory.Workload, selector map[string]string) []*inventory.RootOwner {
+	if len(selector) == 0 {
+		return nil
+	}
+	return matchWorkloads(pods, labels.SelectorFromSet(selector))
+}
+
+// matchWorkloads returns the root owners of the pods matching s
+func matchWorkloads(pods []*inventory.Workload, s labels.Selector) []*inventory.RootOwner {
+	found := make(map[inventory.RootOwner]bool)
+	for _, p := range pods {
+		if !s.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		owner := asRootOwner(p)
+		if p.RootOwner != nil {
+			owner = *p.RootOwner
+		}
+		found[owner] = true
+	}
+	ret := make([]*inventory.RootOwner, 0, len(found))
+	for o := range found {
+		o := o
+		ret = append(ret, &o)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Kind != ret[j].Kind {
+			return ret[i].Kind < ret[j].Kind
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
@@ -0,0 +1,174 @@
+package collect
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// snapshot is a published inventory. Collections build a new inventory and
+// publish it when done, so a snapshot is never modified once published and
+// can be read without locking.
+type snapshot struct {
+	Generation uint64
+	Created    time.Time
+	Inventory  *Inventory
+	// JSON encoding of Inventory
+	JSON []byte
+	// Strong entity tag derived from JSON
+	ETag string
+
+	gzipOnce sync.Once
+	gzipped  []byte
+	gzipErr  error
+
+	api sectionCache
+}
+
+// Gzipped returns the compressed JSON, compressing it on first use
+func (s *snapshot) Gzipped() ([]byte, error) {
+	s.gzipOnce.Do(func() {
+		s.gzipped, s.gzipErr = compress(s.JSON)
+	})
+	return s.gzipped, s.gzipErr
+}
+
+// publish makes i the current inventory. i must not be modified afterwards.
+func (c *InventoryCollection) publish(i *Inventory) {
+	data, err := json.Marshal(i)
+	if err != nil {
+		log.Error().Err(err).Msg("marshaling inventory")
+		return
+	}
+	s := &snapshot{
+		Generation: c.generation.Add(1),
+		Created:    time.Now(),
+		Inventory:  i,
+		JSON:       data,
+		ETag:       `"` + hash(data) + `"`,
+	}
+	c.current.Store(s)
+	if c.history != nil {
+		c.history.add(s)
+	}
+	if c.feed != nil {
+		c.feed.publish(s)
+	}
+	log.Debug().Uint64("generation", s.Generation).Str("etag", s.ETag).Msg("published inventory")
+}
+
+// failedSections returns the sections of the query API filled by collectors
+// that failed. ok is false if the collection failed for other reasons, e.g.
+// because the cluster could not be reached, so no section can be trusted.
+func (s *snapshot) failedSections() (failed map[string]bool, ok bool) {
+	i := s.Inventory
+	if i.CollectionSucceeded {
+		return nil, true
+	}
+	if len(i.CollectorErrors) == 0 || len(i.CollectorErrors) != len(i.CollectionErrors) {
+		return nil, false
+	}
+	collectors := make(map[string]bool)
+	for _, e := range i.CollectorErrors {
+		collectors[e.Collector] = true
+	}
+	failed = make(map[string]bool)
+	for name, section := range apiSections {
+		if collectors[section.collector] {
+			failed[name] = true
+		}
+	}
+	return failed, true
+}
+
+// snapshot returns the current inventory or nil if none has been published
+func (c *InventoryCollection) snapshot() *snapshot {
+	return c.current.Load()
+}
+
+func (c *InventoryCollection) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	s := c.snapshot()
+	if s == nil {
+		http.Error(w, errHTTPNoInventory.JSON(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// Strong validators differ between representations, so the compressed
+	// inventory has its own ETag. Either matches the inventory.
+	gzipped := acceptsGzip(r.Header.Get("Accept-Encoding"))
+	etag := s.ETag
+	if gzipped {
+		etag = gzipETag(s.ETag)
+	}
+	h := w.Header()
+	h.Set("ETag", etag)
+	h.Set("Last-Modified", s.Created.UTC().Format(http.TimeFormat))
+	h.Set("X-Inventory-Generation", strconv.FormatUint(s.Generation, 10))
+	h.Set("Vary", "Accept-Encoding")
+	if inm := r.Header.Get("If-None-Match"); etagMatches(inm, s.ETag) || etagMatches(inm, gzipETag(s.ETag)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.Set("Content-Type", "application/json")
+	body := s.JSON
+	if gzipped {
+		var err error
+		if body, err = s.Gzipped(); err != nil {
+			log.Error().Err(err).Msg("compressing inventory")
+			http.Error(w, errHTTPInternalError.JSON(), http.StatusInternalServerError)
+			return
+		}
+		h.Set("Content-Encoding", "gzip")
+	}
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(body); err != nil {
+		log.Debug().Err(err).Msg("writing inventory")
+	}
+}
+
+// gzipETag returns the ETag of the compressed representation of the
+// inventory with the ETag etag
+func gzipETag(etag string) string {
+	return strings.TrimSuffix(etag, `"`) + `-gzip"`
+}
+
+// etagMatches tells if an If-None-Match header matches etag using the weak
+// comparison of RFC 9110
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip tells if an Accept-Encoding header allows gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, e := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(e), ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q, found := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q=")
+		if !found {
+			return true
+		}
+		if v, err := strconv.ParseFloat(q, 64); err == nil && v > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package collect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServeHTTP(t *testing.T) {
+	c := &InventoryCollection{}
+	i := newInventory()
+	c.publish(i)
+	s := c.snapshot()
+	gzipTag := gzipETag(s.ETag)
+	if gzipTag == s.ETag || gzipTag[len(gzipTag)-1] != '"' {
+		t.Fatalf("gzip ETag: got %s for %s", gzipTag, s.ETag)
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		ifNoneMatch    string
+		code           int
+		etag           string
+		gzipped        bool
+	}{
+		{name: "identity", code: http.StatusOK, etag: s.ETag},
+		{name: "gzip", acceptEncoding: "gzip, deflate", code: http.StatusOK, etag: gzipTag, gzipped: true},
+		{name: "gzip refused", acceptEncoding: "gzip;q=0, deflate", code: http.StatusOK, etag: s.ETag},
+		{name: "any encoding", acceptEncoding: "*", code: http.StatusOK, etag: gzipTag, gzipped: true},
+		{name: "head", method: http.MethodHead, acceptEncoding: "gzip", code: http.StatusOK, etag: gzipTag},
+		{name: "not modified", ifNoneMatch: s.ETag, code: http.StatusNotModified, etag: s.ETag},
+		{name: "not modified gzip", acceptEncoding: "gzip", ifNoneMatch: gzipTag, code: http.StatusNotModified, etag: gzipTag},
+		{name: "not modified weak", ifNoneMatch: `"other", W/` + s.ETag, code: http.StatusNotModified, etag: s.ETag},
+		{name: "not modified any", ifNoneMatch: "*", code: http.StatusNotModified, etag: s.ETag},
+		{name: "modified", acceptEncoding: "gzip", ifNoneMatch: `"other"`, code: http.StatusOK, etag: gzipTag, gzipped: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			c.ServeHTTP(rec, req)
+
+			if rec.Code != tt.code {
+				t.Fatalf("got status %d, want %d", rec.Code, tt.code)
+			}
+			if etag := rec.Header().Get("ETag"); etag != tt.etag {
+				t.Errorf("got ETag %s, want %s", etag, tt.etag)
+			}
+			if rec.Header().Get("Vary") != "Accept-Encoding" {
+				t.Error("Vary not set")
+			}
+			if tt.code == http.StatusNotModified || method == http.MethodHead {
+				if rec.Body.Len() != 0 {
+					t.Errorf("got a body of %d bytes", rec.Body.Len())
+				}
+				return
+			}
+			body := rec.Body.Bytes()
+			if enc := rec.Header().Get("Content-Encoding"); (enc == "gzip") != tt.gzipped {
+				t.Fatalf("got Content-Encoding %q", enc)
+			}
+			if tt.gzipped {
+				zr, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(body, s.JSON) {
+				t.Error("body is not the inventory")
+			}
+		})
+	}
+}
@@ -7,23 +7,19 @@ import (
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	v1 "k8s.io/api/apps/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectStatefulSets(ctx context.Context, cs *ck.Clientset, client client.Client) ([]*inventory.Workload, error) {
+func collectStatefulSets(ctx context.Context, kc client.Client) ([]*inventory.Workload, error) {
 	ssets := make([]*inventory.Workload, 0)
 
-	statefulSetList, err := cs.AppsV1().
-		StatefulSets("").
-		List(ctx, metav1.ListOptions{})
-	if err != nil {
+	statefulSetList := &v1.StatefulSetList{}
+	if err := kc.List(ctx, statefulSetList); err != nil {
 		return nil, fmt.Errorf("getting StatefulSets: %v", err)
 	}
 	var errs []error
 	for _, o := range statefulSetList.Items {
-		sset, err := collectStatefulSet(ctx, client, o)
+		sset, err := collectStatefulSet(ctx, kc, o)
 		errs = append(errs, err)
 		ssets = append(ssets, sset)
 	}
@@ -1,17 +1,21 @@
 package collect
 
 import (
+	"context"
 	"errors"
-
-	inventory "github.com/neticdk-k8s/k8s-inventory"
-	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectStorage(cs *ck.Clientset, i *inventory.Inventory) error {
-	pvs, pvsErr := collectPVs(cs)
+func init() {
+	Register(WithLink(NewCollector("storage", nil, collectStorage), linkPVCs))
+}
+
+func collectStorage(ctx context.Context, cl *Clients, i *Inventory) error {
+	pvs, pvsErr := collectPVs(ctx, cl.Client)
 	i.Storage.PersistentVolumes = pvs
-	sclss, sclssErr := collectStorageClasses(cs)
+	sclss, sclssErr := collectStorageClasses(ctx, cl.Client)
 	i.Storage.StorageClasses = sclss
+	pvcs, pvcsErr := collectPVCs(ctx, cl.Client)
+	i.Storage.PersistentVolumeClaims = pvcs
 
-	return errors.Join(pvsErr, sclssErr)
+	return errors.Join(pvsErr, sclssErr, pvcsErr)
 }
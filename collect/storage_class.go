@@ -3,19 +3,45 @@ package collect
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
+	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	ck "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectStorageClasses(cs *ck.Clientset) ([]*inventory.StorageClass, error) {
-	sclss := make([]*inventory.StorageClass, 0)
-	scList, err := cs.StorageV1().
-		StorageClasses().
-		List(context.Background(), metav1.ListOptions{})
-	if err != nil {
+// Value recorded for parameters that may refer to secrets
+const redacted = "REDACTED"
+
+type StorageClass struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Provisioner          string
+	ReclaimPolicy        string
+	VolumeBindingMode    string
+	AllowVolumeExpansion bool
+	MountOptions         []string `json:",omitempty"`
+	// Parameters with secret references redacted
+	Parameters map[string]string `json:",omitempty"`
+	Default    bool
+}
+
+func NewStorageClass() *StorageClass {
+	return &StorageClass{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "StorageClass",
+			APIGroup:     storagev1.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "storageclasses",
+		},
+	}
+}
+
+func collectStorageClasses(ctx context.Context, kc client.Client) ([]*StorageClass, error) {
+	sclss := make([]*StorageClass, 0)
+	scList := &storagev1.StorageClassList{}
+	if err := kc.List(ctx, scList); err != nil {
 		return nil, fmt.Errorf("getting StorageClasses: %v", err)
 	}
 	for _, o := range scList.Items {
@@ -24,9 +50,40 @@ func collectStorageClasses(cs *ck.Clientset) ([]*inventory.StorageClass, error)
 	return sclss, nil
 }
 
-func collectStorageClass(o storagev1.StorageClass) *inventory.StorageClass {
-	r := inventory.NewStorageClass()
+func collectStorageClass(o storagev1.StorageClass) *StorageClass {
+	r := NewStorageClass()
 	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
 	r.Provisioner = o.Provisioner
+	// Defaults as applied by the API server
+	r.ReclaimPolicy = string(v1.PersistentVolumeReclaimDelete)
+	if o.ReclaimPolicy != nil {
+		r.ReclaimPolicy = string(*o.ReclaimPolicy)
+	}
+	r.VolumeBindingMode = string(storagev1.VolumeBindingImmediate)
+	if o.VolumeBindingMode != nil {
+		r.VolumeBindingMode = string(*o.VolumeBindingMode)
+	}
+	r.AllowVolumeExpansion = o.AllowVolumeExpansion != nil && *o.AllowVolumeExpansion
+	r.MountOptions = o.MountOptions
+	r.Parameters = redactParameters(o.Parameters)
+	r.Default = o.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" ||
+		o.Annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true"
 	return r
 }
+
+// redactParameters returns parameters with the values of those referring to
+// secrets, e.g. csi.storage.k8s.io/provisioner-secret-name, redacted
+func redactParameters(parameters map[string]string) map[string]string {
+	if parameters == nil {
+		return nil
+	}
+	ret := make(map[string]string, len(parameters))
+	for k, v := range parameters {
+		key := strings.ToLower(k)
+		if strings.Contains(key, "secret") || strings.Contains(key, "password") || strings.Contains(key, "token") {
+			v = redacted
+		}
+		ret[k] = v
+	}
+	return ret
+}
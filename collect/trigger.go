@@ -0,0 +1,189 @@
+package collect
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Minimum time between triggered collections
+const defaultTriggerMinInterval = "1m"
+
+// trigger wakes the collection loop on request. Requests made before a
+// collection starts are coalesced into that collection.
+type trigger struct {
+	mu          sync.Mutex
+	ch          chan struct{}
+	minInterval time.Duration
+	last        time.Time
+	queued      bool
+	waiters     []chan *collectResult
+}
+
+// collectResult is reported to those waiting for a triggered collection
+type collectResult struct {
+	Started             time.Time        `json:"started"`
+	Finished            time.Time        `json:"finished"`
+	CollectionSucceeded bool             `json:"collection_succeeded"`
+	CollectionErrors    []string         `json:"collection_errors,omitempty"`
+	CollectorErrors     []CollectorError `json:"collector_errors,omitempty"`
+	Uploaded            bool             `json:"uploaded"`
+	Upload              *metaData        `json:"upload,omitempty"`
+	UploadError         string           `json:"upload_error,omitempty"`
+}
+
+type errRateLimited struct {
+	retryAfter time.Duration
+}
+
+func (e *errRateLimited) Error() string {
+	return fmt.Sprintf("rate limited, retry after %v", e.retryAfter.Round(time.Second))
+}
+
+func newTrigger(minInterval time.Duration) *trigger {
+	return &trigger{ch: make(chan struct{}, 1), minInterval: minInterval}
+}
+
+// request asks for a collection. If wait is true the returned channel
+// receives the result of the collection.
+func (t *trigger) request(wait bool) (<-chan *collectResult, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.queued {
+		if since := time.Since(t.last); since < t.minInterval {
+			return nil, &errRateLimited{retryAfter: t.minInterval - since}
+		}
+		t.last = time.Now()
+		t.queued = true
+		select {
+		case t.ch <- struct{}{}:
+		default:
+		}
+	}
+	if !wait {
+		return nil, nil
+	}
+	ch := make(chan *collectResult, 1)
+	t.waiters = append(t.waiters, ch)
+	return ch, nil
+}
+
+// take is called when a collection starts and returns those waiting for it
+func (t *trigger) take() []chan *collectResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.ch:
+	default:
+	}
+	w := t.waiters
+	t.waiters = nil
+	t.queued = false
+	return w
+}
+
+func (t *trigger) done(waiters []chan *collectResult, res *collectResult) {
+	for _, w := range waiters {
+		w <- res
+	}
+}
+
+// result returns the outcome of the collection started at start
+func (c *InventoryCollection) result(start time.Time, uploadErr error) *collectResult {
+	res := &collectResult{
+		Started:  start,
+		Finished: time.Now(),
+	}
+	if snap := c.snapshot(); snap != nil {
+		res.CollectionSucceeded = snap.Inventory.CollectionSucceeded
+		res.CollectionErrors = snap.Inventory.CollectionErrors
+		res.CollectorErrors = snap.Inventory.CollectorErrors
+	}
+	if uploadErr != nil {
+		res.UploadError = uploadErr.Error()
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if u := c.metaData.Updated; u != nil && u.After(start) {
+		md := *c.metaData
+		res.Uploaded = true
+		res.Upload = &md
+	}
+	return res
+}
+
+// ServeHTTPCollect triggers a collection and upload. The request must carry
+// the trigger token as a bearer token. With wait=true the response is sent
+// when the collection is done, or after timeout.
+func (c *InventoryCollection) ServeHTTPCollect(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, (&errHTTP{StatusCode: http.StatusMethodNotAllowed, Message: "method not allowed"}).JSON(), http.StatusMethodNotAllowed)
+		return
+	}
+	if c.triggerTokenFile == "" {
+		http.Error(w, (&errHTTP{StatusCode: http.StatusNotFound, Message: "triggering collections is not enabled"}).JSON(), http.StatusNotFound)
+		return
+	}
+	if !c.authorizeTrigger(r) {
+		http.Error(w, (&errHTTP{StatusCode: http.StatusUnauthorized, Message: "unauthorized"}).JSON(), http.StatusUnauthorized)
+		return
+	}
+
+	wait, _ := strconv.ParseBool(r.URL.Query().Get("wait"))
+	timeout := c.collectBudget()
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			http.Error(w, (&errHTTP{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("parsing timeout: %v", err)}).JSON(), http.StatusBadRequest)
+			return
+		}
+		timeout = d
+	}
+
+	ch, err := c.trigger.request(wait)
+	if rl, ok := err.(*errRateLimited); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(rl.retryAfter.Seconds())+1))
+		http.Error(w, (&errHTTP{StatusCode: http.StatusTooManyRequests, Message: rl.Error()}).JSON(), http.StatusTooManyRequests)
+		return
+	}
+	log.Info().Str("remote", r.RemoteAddr).Bool("wait", wait).Msg("collection triggered")
+	if !wait {
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "triggered"})
+		return
+	}
+
+	select {
+	case res := <-ch:
+		_ = json.NewEncoder(w).Encode(res)
+	case <-time.After(timeout):
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "triggered", "error": "timed out waiting for collection"})
+	case <-r.Context().Done():
+	}
+}
+
+// authorizeTrigger checks the bearer token against the trigger token file,
+// which is read on every request so the token can be rotated
+func (c *InventoryCollection) authorizeTrigger(r *http.Request) bool {
+	data, err := os.ReadFile(filepath.Clean(c.triggerTokenFile))
+	if err != nil {
+		log.Error().Err(err).Msg("reading trigger token")
+		return false
+	}
+	token := strings.TrimSpace(string(data))
+	auth, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && token != "" && subtle.ConstantTimeCompare([]byte(auth), []byte(token)) == 1
+}
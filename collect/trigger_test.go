@@ -0,0 +1,67 @@
+package collect
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTriggerCoalescing(t *testing.T) {
+	tr := newTrigger(time.Hour)
+
+	first, err := tr.request(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Queued requests join the pending collection, even within the interval
+	second, err := tr.request(true)
+	if err != nil {
+		t.Fatalf("queued request: %v", err)
+	}
+	if _, err := tr.request(false); err != nil {
+		t.Fatalf("queued request without waiting: %v", err)
+	}
+	if len(tr.ch) != 1 {
+		t.Errorf("got %d wake-ups, want 1", len(tr.ch))
+	}
+
+	waiters := tr.take()
+	if len(waiters) != 2 {
+		t.Fatalf("got %d waiters, want 2", len(waiters))
+	}
+	if len(tr.ch) != 0 {
+		t.Error("wake-up not consumed")
+	}
+	res := &collectResult{CollectionSucceeded: true}
+	tr.done(waiters, res)
+	for _, ch := range []<-chan *collectResult{first, second} {
+		if got := <-ch; got != res {
+			t.Errorf("got %+v, want %+v", got, res)
+		}
+	}
+
+	// Once the collection has started new requests are rate limited
+	_, err = tr.request(true)
+	var rl *errRateLimited
+	if !errors.As(err, &rl) {
+		t.Fatalf("got %v, want rate limited", err)
+	}
+	if rl.retryAfter <= 0 || rl.retryAfter > time.Hour {
+		t.Errorf("retry after: got %v", rl.retryAfter)
+	}
+	if w := tr.take(); len(w) != 0 {
+		t.Errorf("rate limited request left %d waiters", len(w))
+	}
+}
+
+func TestTriggerInterval(t *testing.T) {
+	tr := newTrigger(0)
+	for n := 0; n < 3; n++ {
+		if _, err := tr.request(false); err != nil {
+			t.Fatalf("request %d: %v", n, err)
+		}
+		if w := tr.take(); len(w) != 0 {
+			t.Errorf("request %d: got %d waiters", n, len(w))
+		}
+	}
+}
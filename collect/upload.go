@@ -0,0 +1,125 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/neticdk-k8s/k8s-inventory-client/config"
+	"github.com/neticdk-k8s/k8s-inventory-client/spool"
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Default limits for the upload spool
+const (
+	defaultSpoolMaxSize          = "100Mi"
+	defaultSpoolMaxAge           = "24h"
+	defaultUploadRetryBackoff    = "10s"
+	defaultUploadRetryMaxBackoff = "10m"
+)
+
+func newSpool(cfg config.Config) *spool.Spool {
+	maxSize, err := resource.ParseQuantity(cfg.SpoolMaxSize)
+	if err != nil {
+		log.Warn().Err(err).Str("size", cfg.SpoolMaxSize).Msg("parsing spool size")
+		maxSize = resource.MustParse(defaultSpoolMaxSize)
+	}
+	maxAge := parseDuration(cfg.SpoolMaxAge, defaultSpoolMaxAge)
+	s, err := spool.New(cfg.SpoolDir, maxSize.Value(), maxAge)
+	if err != nil {
+		log.Error().Err(err).Str("dir", cfg.SpoolDir).Msg("creating spool. Failed uploads will not be retried.")
+		return nil
+	}
+	log.Info().Str("dir", cfg.SpoolDir).Str("maxSize", maxSize.String()).Dur("maxAge", maxAge).Msg("spooling failed uploads")
+	return s
+}
+
+func (c *InventoryCollection) spoolUpload(contentType string, payload []byte) error {
+	if err := c.spool.Put(contentType, payload); err != nil {
+		return err
+	}
+	select {
+	case c.spoolNotify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// newRetryBackoff returns a backoff starting at base and doubling up to max,
+// with up to 20% jitter added
+func newRetryBackoff(base, max time.Duration) *wait.Backoff {
+	return &wait.Backoff{
+		Duration: base,
+		Factor:   2,
+		Jitter:   0.2,
+		Steps:    math.MaxInt32,
+		Cap:      max,
+	}
+}
+
+// retrySpooled uploads spooled payloads, oldest first, backing off
+// exponentially while the inventory server is unavailable
+func (c *InventoryCollection) retrySpooled(ctx context.Context) {
+	backoff := newRetryBackoff(c.retryBackoff, c.retryMaxBackoff)
+
+	// Entries may be left over from before a restart
+	wake := time.After(0)
+	for {
+		// New entries don't cut a backoff short
+		var notify <-chan struct{}
+		if wake == nil {
+			notify = c.spoolNotify
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-notify:
+		case <-wake:
+		}
+
+		e, err := c.spool.Oldest()
+		if err != nil {
+			log.Error().Err(err).Msg("reading spool")
+		}
+		if e == nil {
+			wake = nil
+			continue
+		}
+
+		_, err = c.send(http.MethodPut, e.ContentType, e.Payload, "")
+		if err != nil && isRetryable(err) {
+			d := backoff.Step()
+			log.Error().Err(err).Str("entry", e.Name).Msgf("retrying spooled upload in %v", d)
+			wake = time.After(d)
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).Str("entry", e.Name).Msg("spooled upload rejected, dropping it")
+		} else {
+			log.Info().Str("entry", e.Name).Time("created", e.Created).Msg("uploaded spooled inventory")
+			backoff = newRetryBackoff(c.retryBackoff, c.retryMaxBackoff)
+		}
+		if err := c.spool.Remove(e); err != nil {
+			log.Error().Err(err).Msg("removing spool entry")
+		}
+		wake = time.After(0)
+	}
+}
+
+// isRetryable tells if an upload may succeed if tried again later. Requests
+// rejected by the server, except for rate limiting and timeouts, will not.
+func isRetryable(err error) bool {
+	var uploadErr *errUpload
+	if !errors.As(err, &uploadErr) {
+		return true
+	}
+	switch uploadErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return uploadErr.StatusCode >= 500
+}
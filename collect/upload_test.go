@@ -0,0 +1,44 @@
+package collect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	b := newRetryBackoff(10*time.Second, time.Minute)
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute, time.Minute}
+	for n, w := range want {
+		d := b.Step()
+		if d < w || d > w+w/5 {
+			t.Errorf("step %d: got %v, want %v with up to 20%% jitter", n, d, w)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("connection refused"), true},
+		{context.DeadlineExceeded, true},
+		{&errUpload{StatusCode: http.StatusInternalServerError}, true},
+		{&errUpload{StatusCode: http.StatusServiceUnavailable}, true},
+		{&errUpload{StatusCode: http.StatusTooManyRequests}, true},
+		{&errUpload{StatusCode: http.StatusRequestTimeout}, true},
+		{fmt.Errorf("uploading: %w", &errUpload{StatusCode: http.StatusBadGateway}), true},
+		{&errUpload{StatusCode: http.StatusBadRequest}, false},
+		{&errUpload{StatusCode: http.StatusUnauthorized}, false},
+		{&errUpload{StatusCode: http.StatusPreconditionFailed}, false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.err); got != tt.want {
+			t.Errorf("%v: got %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
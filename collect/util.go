@@ -2,20 +2,84 @@ package collect
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
+	"github.com/neticdk-k8s/k8s-inventory-client/kubernetes"
 	v1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	ck "k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func readConfigMapByName(cs *ck.Clientset, ns string, name string) (*v1.ConfigMap, error) {
+// listCustomResources reads the objects at path, e.g.
+// /apis/velero.io/v1/backups, into list. It returns false if the resource
+// is not served.
+func listCustomResources(ctx context.Context, cs *ck.Clientset, path string, list runtime.Object) (bool, error) {
+	res, found, err := kubernetes.GetK8SRESTResource(ctx, cs, path)
+	if err != nil || !found {
+		return false, err
+	}
+	if err := res.Into(list); err != nil {
+		return false, fmt.Errorf("decoding %s: %v", path, err)
+	}
+	return true, nil
+}
+
+// listCustomObjects reads the objects at path like listCustomResources and
+// converts each of them to a T. It is used for custom resources whose API
+// packages are not among the dependencies, so T only has to declare the
+// fields read. Objects which can't be converted are left out and reported in
+// the error.
+func listCustomObjects[T any](ctx context.Context, cs *ck.Clientset, path string) ([]T, bool, error) {
+	list := &unstructured.UnstructuredList{}
+	if found, err := listCustomResources(ctx, cs, path, list); !found {
+		return nil, false, err
+	}
+	objs, err := convertCustomObjects[T](list.Items)
+	return objs, true, err
+}
+
+func convertCustomObjects[T any](items []unstructured.Unstructured) ([]T, error) {
+	objs := make([]T, 0, len(items))
+	var errs []error
+	for _, u := range items {
+		var o T
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &o); err != nil {
+			errs = append(errs, fmt.Errorf("converting %s %s/%s: %v", u.GetKind(), u.GetNamespace(), u.GetName(), err))
+			continue
+		}
+		objs = append(objs, o)
+	}
+	return objs, errors.Join(errs...)
+}
+
+func labelSelector(s *metav1.LabelSelector) *inventory.LabelSelector {
+	if s == nil {
+		return nil
+	}
+	r := &inventory.LabelSelector{
+		MatchLabels:      s.MatchLabels,
+		MatchExpressions: make([]inventory.LabelSelectorRequirement, 0, len(s.MatchExpressions)),
+	}
+	for _, e := range s.MatchExpressions {
+		r.MatchExpressions = append(r.MatchExpressions, inventory.LabelSelectorRequirement{
+			Key:      e.Key,
+			Operator: string(e.Operator),
+			Values:   e.Values,
+		})
+	}
+	return r
+}
+
+func readConfigMapByName(ctx context.Context, cs *ck.Clientset, ns string, name string) (*v1.ConfigMap, error) {
 	res, err := cs.CoreV1().
-		ConfigMaps(ns).Get(context.TODO(), name, metav1.GetOptions{})
+		ConfigMaps(ns).Get(ctx, name, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -78,10 +142,12 @@ func resolveRootOwner(ctx context.Context, kc client.Client, obj client.Object)
 	return nil, nil, nil
 }
 
-func resolveOwnerChain(ctx context.Context, kc client.Client, namespace string, owner *metav1.OwnerReference) (*unstructured.Unstructured, error) {
-	obj := &unstructured.Unstructured{}
-	obj.SetAPIVersion(owner.APIVersion)
-	obj.SetKind(owner.Kind)
+// resolveOwnerChain follows controller references from owner until it reaches
+// an object without a controller. Only object metadata is read, which allows
+// the lookups to be served from a metadata informer when caching is enabled.
+func resolveOwnerChain(ctx context.Context, kc client.Client, namespace string, owner *metav1.OwnerReference) (*metav1.PartialObjectMetadata, error) {
+	obj := &metav1.PartialObjectMetadata{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind))
 	err := kc.Get(ctx, client.ObjectKey{Namespace: namespace, Name: owner.Name}, obj)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
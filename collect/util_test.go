@@ -0,0 +1,43 @@
+package collect
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConvertCustomObjects(t *testing.T) {
+	policy := func(name string, background any) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "kyverno.io/v1",
+			"kind":       "ClusterPolicy",
+			"metadata":   map[string]any{"name": name},
+			"spec":       map[string]any{"background": background},
+		}}
+	}
+	objs, err := convertCustomObjects[kyvernoPolicy]([]unstructured.Unstructured{
+		policy("first", true),
+		policy("odd", "yes"),
+		policy("last", false),
+	})
+	if err == nil || !strings.Contains(err.Error(), "ClusterPolicy /odd") {
+		t.Errorf("got error %v", err)
+	}
+	var got []string
+	for _, o := range objs {
+		got = append(got, o.Name)
+	}
+	if strings.Join(got, ",") != "first,last" {
+		t.Errorf("got %v, want the objects after the odd one too", got)
+	}
+}
+
+func TestCollectPolicyReportsNotServed(t *testing.T) {
+	// The clientset is nil, so listing reports would panic
+	reports, err := collectPolicyReports(context.Background(), &Clients{APIResources: map[string]bool{"kyverno.io/v1/clusterpolicies": true}})
+	if err != nil || len(reports) != 0 {
+		t.Errorf("got %v, %v", reports, err)
+	}
+}
@@ -1,16 +1,31 @@
 package collect
 
 import (
+	"context"
+	"errors"
+
 	inventory "github.com/neticdk-k8s/k8s-inventory"
 	"github.com/neticdk-k8s/k8s-inventory-client/kubernetes"
 	veleroapi "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
 	ck "k8s.io/client-go/kubernetes"
 )
 
-func collectVeleroBackups(cs *ck.Clientset) ([]*inventory.VeleroBackup, error) {
+func init() {
+	Register(NewCollector("velero", []string{"velero.io/v1/backups"}, collectVelero))
+}
+
+func collectVelero(ctx context.Context, cl *Clients, i *Inventory) error {
+	backups, backupsErr := collectVeleroBackups(ctx, cl.Clientset)
+	i.CustomResources.Velero.Backups = backups
+	schedules, schedulesErr := collectVeleroSchedules(ctx, cl.Clientset)
+	i.CustomResources.Velero.Schedules = schedules
+	return errors.Join(backupsErr, schedulesErr)
+}
+
+func collectVeleroBackups(ctx context.Context, cs *ck.Clientset) ([]*inventory.VeleroBackup, error) {
 	veleroBackups := make([]*inventory.VeleroBackup, 0)
 
-	res, found, err := kubernetes.GetK8SRESTResource(cs, "/apis/velero.io/v1/backups")
+	res, found, err := kubernetes.GetK8SRESTResource(ctx, cs, "/apis/velero.io/v1/backups")
 	if err != nil {
 		return nil, err
 	}
@@ -54,10 +69,10 @@ func collectVeleroBackups(cs *ck.Clientset) ([]*inventory.VeleroBackup, error) {
 	return veleroBackups, nil
 }
 
-func collectVeleroSchedules(cs *ck.Clientset) ([]*inventory.VeleroSchedule, error) {
+func collectVeleroSchedules(ctx context.Context, cs *ck.Clientset) ([]*inventory.VeleroSchedule, error) {
 	veleroSchedules := make([]*inventory.VeleroSchedule, 0)
 
-	res, found, err := kubernetes.GetK8SRESTResource(cs, "/apis/velero.io/v1/schedules")
+	res, found, err := kubernetes.GetK8SRESTResource(ctx, cs, "/apis/velero.io/v1/schedules")
 	if err != nil {
 		return nil, err
 	}
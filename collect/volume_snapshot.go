@@ -0,0 +1,141 @@
+package collect
+
+import (
+	"context"
+	"errors"
+
+	snapshotapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	inventory "github.com/neticdk-k8s/k8s-inventory"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ck "k8s.io/client-go/kubernetes"
+)
+
+func init() {
+	Register(NewCollector("volume_snapshot", []string{"snapshot.storage.k8s.io/v1/volumesnapshots"}, collectVolumeSnapshots))
+}
+
+type VolumeSnapshotClass struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Driver               string
+	DeletionPolicy       string
+	// Parameters with secret references redacted
+	Parameters map[string]string `json:",omitempty"`
+	Default    bool
+}
+
+type VolumeSnapshot struct {
+	inventory.TypeMeta   `json:",inline"`
+	inventory.ObjectMeta `json:"metadata"`
+	Spec                 VolumeSnapshotSpec   `json:"spec"`
+	Status               VolumeSnapshotStatus `json:"status"`
+}
+
+type VolumeSnapshotSpec struct {
+	// PersistentVolumeClaimName is set for snapshots taken of a claim
+	PersistentVolumeClaimName string `json:",omitempty"`
+	// VolumeSnapshotContentName is set for pre-provisioned snapshots
+	VolumeSnapshotContentName string `json:",omitempty"`
+	VolumeSnapshotClassName   string
+}
+
+type VolumeSnapshotStatus struct {
+	ReadyToUse bool
+	// RestoreSize is the minimum size in bytes of a volume restored from the
+	// snapshot
+	RestoreSize  int64
+	CreationTime *metav1.Time `json:",omitempty"`
+	BoundContent string       `json:",omitempty"`
+	Error        string       `json:",omitempty"`
+}
+
+func NewVolumeSnapshotClass() *VolumeSnapshotClass {
+	return &VolumeSnapshotClass{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "VolumeSnapshotClass",
+			APIGroup:     snapshotapi.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "volumesnapshotclasses",
+		},
+	}
+}
+
+func NewVolumeSnapshot() *VolumeSnapshot {
+	return &VolumeSnapshot{
+		TypeMeta: inventory.TypeMeta{
+			Kind:         "VolumeSnapshot",
+			APIGroup:     snapshotapi.GroupName,
+			APIVersion:   "v1",
+			ResourceType: "volumesnapshots",
+		},
+	}
+}
+
+func collectVolumeSnapshots(ctx context.Context, cl *Clients, i *Inventory) error {
+	classes, classesErr := collectVolumeSnapshotClasses(ctx, cl.Clientset)
+	i.Storage.VolumeSnapshotClasses = classes
+	snapshots, snapshotsErr := collectVolumeSnapshotList(ctx, cl.Clientset)
+	i.Storage.VolumeSnapshots = snapshots
+	return errors.Join(classesErr, snapshotsErr)
+}
+
+func collectVolumeSnapshotClasses(ctx context.Context, cs *ck.Clientset) ([]*VolumeSnapshotClass, error) {
+	classes := make([]*VolumeSnapshotClass, 0)
+	list := &snapshotapi.VolumeSnapshotClassList{}
+	if found, err := listCustomResources(ctx, cs, "/apis/snapshot.storage.k8s.io/v1/volumesnapshotclasses", list); !found {
+		return classes, err
+	}
+	for _, o := range list.Items {
+		r := NewVolumeSnapshotClass()
+		r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+		r.Driver = o.Driver
+		r.DeletionPolicy = string(o.DeletionPolicy)
+		r.Parameters = redactParameters(o.Parameters)
+		r.Default = o.Annotations["snapshot.storage.kubernetes.io/is-default-class"] == "true"
+		classes = append(classes, r)
+	}
+	return classes, nil
+}
+
+func collectVolumeSnapshotList(ctx context.Context, cs *ck.Clientset) ([]*VolumeSnapshot, error) {
+	snapshots := make([]*VolumeSnapshot, 0)
+	list := &snapshotapi.VolumeSnapshotList{}
+	if found, err := listCustomResources(ctx, cs, "/apis/snapshot.storage.k8s.io/v1/volumesnapshots", list); !found {
+		return snapshots, err
+	}
+	for _, o := range list.Items {
+		snapshots = append(snapshots, collectVolumeSnapshot(o))
+	}
+	return snapshots, nil
+}
+
+func collectVolumeSnapshot(o snapshotapi.VolumeSnapshot) *VolumeSnapshot {
+	r := NewVolumeSnapshot()
+	r.ObjectMeta = inventory.NewObjectMeta(o.ObjectMeta)
+	if o.Spec.Source.PersistentVolumeClaimName != nil {
+		r.Spec.PersistentVolumeClaimName = *o.Spec.Source.PersistentVolumeClaimName
+	}
+	if o.Spec.Source.VolumeSnapshotContentName != nil {
+		r.Spec.VolumeSnapshotContentName = *o.Spec.Source.VolumeSnapshotContentName
+	}
+	if o.Spec.VolumeSnapshotClassName != nil {
+		r.Spec.VolumeSnapshotClassName = *o.Spec.VolumeSnapshotClassName
+	}
+
+	s := o.Status
+	if s == nil {
+		return r
+	}
+	r.Status.ReadyToUse = s.ReadyToUse != nil && *s.ReadyToUse
+	if s.RestoreSize != nil {
+		r.Status.RestoreSize = s.RestoreSize.Value()
+	}
+	r.Status.CreationTime = s.CreationTime
+	if s.BoundVolumeSnapshotContentName != nil {
+		r.Status.BoundContent = *s.BoundVolumeSnapshotContentName
+	}
+	if s.Error != nil && s.Error.Message != nil {
+		r.Status.Error = *s.Error.Message
+	}
+	return r
+}
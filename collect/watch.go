@@ -0,0 +1,344 @@
+package collect
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Types of watch events, as in the Kubernetes API
+const (
+	watchAdded    = "ADDED"
+	watchModified = "MODIFIED"
+	watchDeleted  = "DELETED"
+	watchBookmark = "BOOKMARK"
+	watchError    = "ERROR"
+)
+
+// Time between bookmarks sent to idle watchers, which also keeps proxies from
+// closing the connection
+const watchHeartbeat = 30 * time.Second
+
+type watchEvent struct {
+	Type string `json:"type"`
+	// Cursor to resume from after this event. Not set on the events listing
+	// the inventory when a watch starts.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	Generation      uint64 `json:"generation"`
+	Section         string `json:"section,omitempty"`
+	Kind            string `json:"kind,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name,omitempty"`
+	Object          any    `json:"object,omitempty"`
+
+	labels labels.Set
+}
+
+// changeFeed keeps the last size changes between published snapshots.
+// Cursors are the sequence number of the next event prefixed with the time
+// the feed was created, so cursors from before a restart are rejected.
+type changeFeed struct {
+	mu     sync.Mutex
+	epoch  int64
+	size   int
+	events []watchEvent
+	// Sequence number of the next event
+	next uint64
+	// Generation and objects of the last snapshot published, with the
+	// sections of failed collectors carried forward from earlier snapshots
+	generation uint64
+	sections   map[string][]apiObject
+	// Closed and replaced when events are added
+	notify chan struct{}
+}
+
+func newChangeFeed(size int) *changeFeed {
+	return &changeFeed{epoch: time.Now().Unix(), size: size, notify: make(chan struct{})}
+}
+
+// publish records the changes from the last snapshot to s. Snapshots of
+// failed collections are skipped, and the sections of collectors that failed
+// are kept as they were, so a transient failure doesn't show up as every
+// object being deleted and added again.
+func (f *changeFeed) publish(s *snapshot) {
+	failed, ok := s.failedSections()
+	if !ok {
+		log.Debug().Uint64("generation", s.Generation).Msg("collection failed, not recording changes")
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sections := make(map[string][]apiObject, len(apiSections))
+	for _, name := range sectionNames() {
+		if objs, found := f.sections[name]; found && failed[name] {
+			sections[name] = objs
+			continue
+		}
+		objs, err := s.section(name)
+		if err != nil {
+			log.Error().Err(err).Msg("computing changes")
+			return
+		}
+		sections[name] = objs
+	}
+	if f.sections != nil {
+		for _, e := range sectionEvents(f.sections, sections, s.Generation) {
+			f.next++
+			e.ResourceVersion = f.cursor(f.next)
+			f.events = append(f.events, e)
+		}
+		if n := len(f.events) - f.size; n > 0 {
+			f.events = append([]watchEvent(nil), f.events[n:]...)
+		}
+	}
+	f.generation = s.Generation
+	f.sections = sections
+	close(f.notify)
+	f.notify = make(chan struct{})
+}
+
+func (f *changeFeed) cursor(seq uint64) string {
+	return fmt.Sprintf("%d-%d", f.epoch, seq)
+}
+
+// feedPosition is where a watcher is in the feed
+type feedPosition struct {
+	cursor     string
+	generation uint64
+	// Closed when events are added after cursor
+	notify <-chan struct{}
+}
+
+func (f *changeFeed) position() feedPosition {
+	return feedPosition{cursor: f.cursor(f.next), generation: f.generation, notify: f.notify}
+}
+
+// since returns the events after cursor and the position following them. ok
+// is false if events after cursor are no longer kept.
+func (f *changeFeed) since(cursor string) (events []watchEvent, p feedPosition, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	epoch, seq, found := strings.Cut(cursor, "-")
+	e, err1 := strconv.ParseInt(epoch, 10, 64)
+	n, err2 := strconv.ParseUint(seq, 10, 64)
+	first := f.next - uint64(len(f.events))
+	if !found || err1 != nil || err2 != nil || e != f.epoch || n < first || n > f.next {
+		return nil, p, false
+	}
+	return f.events[n-first:], f.position(), true
+}
+
+// head returns the objects of the last snapshot, or nil if none has been
+// published, and the position following them
+func (f *changeFeed) head() (map[string][]apiObject, feedPosition) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sections, f.position()
+}
+
+// sectionEvents compares two sets of sections object by object. The objects
+// of each section must be sorted by key.
+func sectionEvents(from, to map[string][]apiObject, generation uint64) []watchEvent {
+	var events []watchEvent
+	for _, name := range sectionNames() {
+		a, b := from[name], to[name]
+		for len(a) > 0 || len(b) > 0 {
+			switch {
+			case len(b) == 0 || (len(a) > 0 && a[0].key < b[0].key):
+				events = append(events, objectEvent(watchDeleted, generation, name, a[0]))
+				a = a[1:]
+			case len(a) == 0 || b[0].key < a[0].key:
+				events = append(events, objectEvent(watchAdded, generation, name, b[0]))
+				b = b[1:]
+			default:
+				if !reflect.DeepEqual(a[0].object, b[0].object) {
+					events = append(events, objectEvent(watchModified, generation, name, b[0]))
+				}
+				a, b = a[1:], b[1:]
+			}
+		}
+	}
+	return events
+}
+
+func objectEvent(typ string, generation uint64, section string, o apiObject) watchEvent {
+	ref := refOf(o)
+	return watchEvent{
+		Type:       typ,
+		Generation: generation,
+		Section:    section,
+		Kind:       ref.Kind,
+		Namespace:  ref.Namespace,
+		Name:       ref.Name,
+		Object:     o.object,
+		labels:     o.labels,
+	}
+}
+
+func sectionNames() []string {
+	names := make([]string, 0, len(apiSections))
+	for name := range apiSections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// watchFilter selects the events sent to a watcher
+type watchFilter struct {
+	section, namespace, kind string
+	selector                 labels.Selector
+}
+
+func (wf watchFilter) matches(e watchEvent) bool {
+	return (wf.section == "" || e.Section == wf.section) &&
+		(wf.namespace == "" || e.Namespace == wf.namespace) &&
+		(wf.kind == "" || strings.EqualFold(e.Kind, wf.kind)) &&
+		wf.selector.Matches(e.labels)
+}
+
+// watchWriter writes events as Server-Sent Events or newline delimited JSON
+type watchWriter struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	sse bool
+}
+
+func (ww *watchWriter) write(e watchEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if ww.sse {
+		// Only complete cursors are used as event IDs, so Last-Event-ID is
+		// always safe to resume from
+		if e.ResourceVersion != "" {
+			if _, err := fmt.Fprintf(ww.w, "id: %s\n", e.ResourceVersion); err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(ww.w, "event: %s\ndata: %s\n\n", e.Type, data)
+	} else {
+		_, err = fmt.Fprintf(ww.w, "%s\n", data)
+	}
+	return err
+}
+
+func (ww *watchWriter) flush() error {
+	return ww.rc.Flush()
+}
+
+// serveWatch streams changes to the inventory. Without a resourceVersion the
+// objects of the current snapshot are sent as ADDED events first.
+func (c *InventoryCollection) serveWatch(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if c.feed == nil {
+		apiError(w, http.StatusNotFound, "watch is not enabled")
+		return
+	}
+	q := r.URL.Query()
+	selector, err := labels.Parse(q.Get("labelSelector"))
+	if err != nil {
+		apiError(w, http.StatusBadRequest, fmt.Sprintf("parsing labelSelector: %v", err))
+		return
+	}
+	filter := watchFilter{section: q.Get("section"), namespace: q.Get("namespace"), kind: q.Get("kind"), selector: selector}
+	if _, found := apiSections[filter.section]; filter.section != "" && !found {
+		apiError(w, http.StatusBadRequest, fmt.Sprintf("unknown section %q", filter.section))
+		return
+	}
+	cursor := q.Get("resourceVersion")
+	if cursor == "" {
+		cursor = r.Header.Get("Last-Event-ID")
+	}
+
+	ww := &watchWriter{w: w, rc: http.NewResponseController(w), sse: q.Get("format") == "sse" || strings.Contains(r.Header.Get("Accept"), "text/event-stream")}
+	var (
+		events []watchEvent
+		pos    feedPosition
+		ok     bool
+	)
+	if cursor == "" {
+		var sections map[string][]apiObject
+		if sections, pos = c.feed.head(); sections == nil {
+			http.Error(w, errHTTPNoInventory.JSON(), http.StatusServiceUnavailable)
+			return
+		}
+		events = listEvents(sections, pos.generation)
+	} else if events, pos, ok = c.feed.since(cursor); !ok {
+		apiError(w, http.StatusGone, "resourceVersion is too old or unknown, watch without it to start over")
+		return
+	}
+
+	if ww.sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+
+	send := func(events []watchEvent, pos feedPosition) error {
+		for _, e := range events {
+			if !filter.matches(e) {
+				continue
+			}
+			if err := ww.write(e); err != nil {
+				return err
+			}
+		}
+		// The bookmark carries the cursor past events that were filtered out
+		if err := ww.write(watchEvent{Type: watchBookmark, ResourceVersion: pos.cursor, Generation: pos.generation}); err != nil {
+			return err
+		}
+		return ww.flush()
+	}
+	if err := send(events, pos); err != nil {
+		log.Debug().Err(err).Msg("writing watch events")
+		return
+	}
+
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			events = nil
+		case <-pos.notify:
+			if events, pos, ok = c.feed.since(pos.cursor); !ok {
+				// The watcher fell more than the feed size behind
+				e := watchEvent{Type: watchError, Object: errHTTP{StatusCode: http.StatusGone, Message: "too many changes, watch without resourceVersion to start over"}}
+				if err := ww.write(e); err == nil {
+					_ = ww.flush()
+				}
+				return
+			}
+		}
+		if err := send(events, pos); err != nil {
+			log.Debug().Err(err).Msg("writing watch events")
+			return
+		}
+	}
+}
+
+// listEvents lists the objects of sections as ADDED events
+func listEvents(sections map[string][]apiObject, generation uint64) []watchEvent {
+	var events []watchEvent
+	for _, name := range sectionNames() {
+		for _, o := range sections[name] {
+			events = append(events, objectEvent(watchAdded, generation, name, o))
+		}
+	}
+	return events
+}
@@ -0,0 +1,165 @@
+package collect
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testEvent struct {
+	typ, section, name string
+}
+
+func eventsOf(events []watchEvent) []testEvent {
+	var ret []testEvent
+	for _, e := range events {
+		ret = append(ret, testEvent{e.Type, e.Section, e.Name})
+	}
+	return ret
+}
+
+func TestSectionEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to map[string]string
+		want     []testEvent
+	}{
+		{
+			name: "unchanged",
+			from: map[string]string{"nodes": `[{"kind":"Node","metadata":{"name":"a"}}]`},
+			to:   map[string]string{"nodes": `[{"kind":"Node","metadata":{"name":"a"}}]`},
+		},
+		{
+			name: "added, modified and deleted",
+			from: map[string]string{"nodes": `[{"kind":"Node","metadata":{"name":"a"}},{"kind":"Node","metadata":{"name":"b"},"spec":1}]`},
+			to:   map[string]string{"nodes": `[{"kind":"Node","metadata":{"name":"b"},"spec":2},{"kind":"Node","metadata":{"name":"c"}}]`},
+			want: []testEvent{{watchDeleted, "nodes", "a"}, {watchModified, "nodes", "b"}, {watchAdded, "nodes", "c"}},
+		},
+		{
+			name: "sections in order",
+			from: map[string]string{},
+			to: map[string]string{
+				"workloads":  `[{"kind":"Pod","metadata":{"name":"p","namespace":"x"}}]`,
+				"namespaces": `[{"kind":"Namespace","metadata":{"name":"x"}}]`,
+			},
+			want: []testEvent{{watchAdded, "namespaces", "x"}, {watchAdded, "workloads", "p"}},
+		},
+		{
+			name: "section emptied",
+			from: map[string]string{"nodes": `[{"kind":"Node","metadata":{"name":"a"}}]`},
+			to:   map[string]string{},
+			want: []testEvent{{watchDeleted, "nodes", "a"}},
+		},
+	}
+	sections := func(t *testing.T, docs map[string]string) map[string][]apiObject {
+		ret := make(map[string][]apiObject)
+		for name, doc := range docs {
+			ret[name] = testObjects(t, doc)
+		}
+		return ret
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := sectionEvents(sections(t, tt.from), sections(t, tt.to), 3)
+			if got := eventsOf(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			for _, e := range events {
+				if e.Generation != 3 || e.Object == nil {
+					t.Errorf("got %+v", e)
+				}
+			}
+		})
+	}
+}
+
+// testSnapshot returns a snapshot with the nodes given and every other section
+// empty
+func testSnapshot(t *testing.T, generation uint64, nodes string, i *Inventory) *snapshot {
+	t.Helper()
+	s := &snapshot{Generation: generation, Inventory: i}
+	s.api.sections = make(map[string][]apiObject)
+	for name := range apiSections {
+		s.api.sections[name] = nil
+	}
+	s.api.sections["nodes"] = testObjects(t, nodes)
+	return s
+}
+
+func TestChangeFeedPublish(t *testing.T) {
+	succeeded := NewInventory()
+	succeeded.CollectionSucceeded = true
+	nodeFailed := NewInventory()
+	nodeFailed.CollectionErrors = []string{"node failed"}
+	nodeFailed.CollectorErrors = []CollectorError{{Collector: "node"}}
+	failed := NewInventory()
+	failed.CollectionErrors = []string{"failed"}
+
+	f := newChangeFeed(10)
+	f.publish(testSnapshot(t, 1, `[{"kind":"Node","metadata":{"name":"a"}},{"kind":"Node","metadata":{"name":"b"}}]`, succeeded))
+	// Nodes are carried forward when their collector fails
+	f.publish(testSnapshot(t, 2, `[]`, nodeFailed))
+	// Failed collections are skipped
+	f.publish(testSnapshot(t, 3, `[]`, failed))
+	f.publish(testSnapshot(t, 4, `[{"kind":"Node","metadata":{"name":"b"}},{"kind":"Node","metadata":{"name":"c"}}]`, succeeded))
+
+	sections, pos := f.head()
+	if pos.generation != 4 || len(sections["nodes"]) != 2 {
+		t.Errorf("head: got generation %d and %d nodes", pos.generation, len(sections["nodes"]))
+	}
+	events, _, ok := f.since(f.cursor(0))
+	if !ok {
+		t.Fatal("events from the start not kept")
+	}
+	want := []testEvent{{watchDeleted, "nodes", "a"}, {watchAdded, "nodes", "c"}}
+	if got := eventsOf(events); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for n, e := range events {
+		if e.Generation != 4 || e.ResourceVersion != f.cursor(uint64(n+1)) {
+			t.Errorf("event %d: got generation %d and cursor %s", n, e.Generation, e.ResourceVersion)
+		}
+	}
+}
+
+func TestChangeFeedSince(t *testing.T) {
+	succeeded := NewInventory()
+	succeeded.CollectionSucceeded = true
+	f := newChangeFeed(2)
+	f.publish(testSnapshot(t, 1, `[]`, succeeded))
+	f.publish(testSnapshot(t, 2, `[{"kind":"Node","metadata":{"name":"a"}},{"kind":"Node","metadata":{"name":"b"}},{"kind":"Node","metadata":{"name":"c"}}]`, succeeded))
+
+	tests := []struct {
+		cursor string
+		events []string
+		ok     bool
+	}{
+		{cursor: f.cursor(1), events: []string{"b", "c"}, ok: true},
+		{cursor: f.cursor(2), events: []string{"c"}, ok: true},
+		{cursor: f.cursor(3), ok: true},
+		// Only the last two events are kept
+		{cursor: f.cursor(0)},
+		{cursor: f.cursor(4)},
+		{cursor: "1-1"},
+		{cursor: "1"},
+		{cursor: "x-1"},
+		{cursor: f.cursor(1) + "x"},
+		{cursor: ""},
+	}
+	for _, tt := range tests {
+		events, pos, ok := f.since(tt.cursor)
+		if ok != tt.ok {
+			t.Errorf("%q: got %v, want %v", tt.cursor, ok, tt.ok)
+			continue
+		}
+		var names []string
+		for _, e := range events {
+			names = append(names, e.Name)
+		}
+		if !reflect.DeepEqual(names, tt.events) {
+			t.Errorf("%q: got %v, want %v", tt.cursor, names, tt.events)
+		}
+		if ok && (pos.cursor != f.cursor(3) || pos.generation != 2) {
+			t.Errorf("%q: got position %s at generation %d", tt.cursor, pos.cursor, pos.generation)
+		}
+	}
+}
@@ -5,33 +5,83 @@ import (
 	"errors"
 
 	inventory "github.com/neticdk-k8s/k8s-inventory"
-	ck "k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func collectWorkloads(ctx context.Context, cs *ck.Clientset, client client.Client, i *inventory.Inventory) error {
+func init() {
+	Register(NewCollector("workload", nil, collectWorkloads))
+}
+
+// WorkloadLinks is what is linked to a workload of the inventory
+type WorkloadLinks struct {
+	Workload                 Reference
+	HorizontalPodAutoscalers []Reference `json:",omitempty"`
+	VerticalPodAutoscalers   []Reference `json:",omitempty"`
+	PodDisruptionBudgets     []Reference `json:",omitempty"`
+	// PersistentVolumeClaims mounted by the pods of the workload
+	PersistentVolumeClaims []Reference `json:",omitempty"`
+	// Flux lists the Kustomizations and HelmReleases managing the workload
+	Flux []Reference `json:",omitempty"`
+}
+
+// workloadLinks returns the links of the workload o, adding them to the
+// inventory if it has none yet. Linkers run one at a time, so no locking is
+// needed.
+func (i *Inventory) workloadLinks(o inventory.RootOwner) *WorkloadLinks {
+	group := o.APIGroup
+	if group == "core" {
+		group = ""
+	}
+	ref := Reference{APIGroup: group, Kind: o.Kind, Namespace: o.Namespace, Name: o.Name}
+	if i.workloadLinkIndex == nil {
+		i.workloadLinkIndex = make(map[Reference]*WorkloadLinks)
+		for _, l := range i.WorkloadLinks {
+			i.workloadLinkIndex[l.Workload] = l
+		}
+	}
+	l, found := i.workloadLinkIndex[ref]
+	if !found {
+		l = &WorkloadLinks{Workload: ref}
+		i.workloadLinkIndex[ref] = l
+		i.WorkloadLinks = append(i.WorkloadLinks, l)
+	}
+	return l
+}
+
+// asRootOwner returns w as the root owner of itself. Owners have an empty
+// group for the core API like owner references, while workloads use "core".
+func asRootOwner(w *inventory.Workload) inventory.RootOwner {
+	group := w.APIGroup
+	if group == "core" {
+		group = ""
+	}
+	return inventory.RootOwner{Kind: w.Kind, APIGroup: group, APIVersion: w.APIVersion, Name: w.Name, Namespace: w.Namespace}
+}
+
+func collectWorkloads(ctx context.Context, cl *Clients, i *Inventory) error {
+	kc := cl.Client
 	i.Workloads = make([]*inventory.Workload, 0)
 
-	deployments, deploymentsErr := collectDeployments(ctx, cs, client)
+	deployments, deploymentsErr := collectDeployments(ctx, kc)
 	i.Workloads = append(i.Workloads, deployments...)
 
-	statefulSets, statefulSetsErr := collectStatefulSets(ctx, cs, client)
+	statefulSets, statefulSetsErr := collectStatefulSets(ctx, kc)
 	i.Workloads = append(i.Workloads, statefulSets...)
 
-	replicaSets, replicaSetsErr := collectReplicaSets(ctx, cs, client)
+	replicaSets, replicaSetsErr := collectReplicaSets(ctx, kc)
 	i.Workloads = append(i.Workloads, replicaSets...)
 
-	daemonSets, daemonSetsErr := collectDaemonSets(ctx, cs, client)
+	daemonSets, daemonSetsErr := collectDaemonSets(ctx, kc)
 	i.Workloads = append(i.Workloads, daemonSets...)
 
-	cronJobs, cronJobErr := collectCronJobs(ctx, cs, client)
+	cronJobs, cronJobErr := collectCronJobs(ctx, kc)
 	i.Workloads = append(i.Workloads, cronJobs...)
 
-	jobs, jobsErr := collectJobs(ctx, cs, client)
+	jobs, jobsErr := collectJobs(ctx, kc)
 	i.Workloads = append(i.Workloads, jobs...)
 
-	pods, owners, podsErr := collectPods(ctx, cs, client)
+	pods, owners, claims, podsErr := collectPods(ctx, kc)
 	i.Workloads = append(i.Workloads, pods...)
+	i.podClaims = claims
 
 	// Append all pod owners that is _not_ already part of the collection
 	for _, o := range owners {
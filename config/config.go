@@ -10,17 +10,38 @@ type Config struct {
 		Level     string `env:"LOG_LEVEL,default=info"`
 		Formatter string `env:"LOG_FORMATTER,default=json"`
 	}
-	CollectionInterval string `env:"COLLECT_INTERVAL,default=1h"`
-	UploadInventory    bool   `env:"UPLOAD_INVENTORY,default=true"`
-	Impersonate        string `env:"IMPERSONATE"`
-	ServerAPIEndpoint  string `env:"SERVER_API_ENDPOINT,default=http://localhost:8086"`
-	HTTPPort           string `env:"HTTP_PORT,default=8087"`
-	HTTPPortMeta       string `env:"HTTP_PORT_META,default=8088"`
-	TLSCrt             string `env:"TLS_CRT,default=/etc/certificates/tls.crt"`
-	TLSKey             string `env:"TLS_KEY,default=/etc/certificates/tls.key"`
-	AuthEnabled        bool   `env:"AUTH_ENABLED,default=true"`
-	Debug              bool   `env:"DEBUG,default=false"`
-	Extras             env.EnvSet
+	CollectionInterval    string `env:"COLLECT_INTERVAL,default=1h"`
+	CacheEnabled          bool   `env:"CACHE_ENABLED,default=false"`
+	CacheRefreshInterval  string `env:"CACHE_REFRESH_INTERVAL,default=30s"`
+	EnabledCollectors     string `env:"ENABLED_COLLECTORS"`
+	DisabledCollectors    string `env:"DISABLED_COLLECTORS"`
+	CollectConcurrency    int    `env:"COLLECT_CONCURRENCY,default=4"`
+	CollectTimeout        string `env:"COLLECT_TIMEOUT,default=5m"`
+	CertificateExpiryDays int    `env:"CERTIFICATE_EXPIRY_DAYS,default=30"`
+	UploadInventory       bool   `env:"UPLOAD_INVENTORY,default=true"`
+	Impersonate           string `env:"IMPERSONATE"`
+	ServerAPIEndpoint     string `env:"SERVER_API_ENDPOINT,default=http://localhost:8086"`
+	SpoolDir              string `env:"SPOOL_DIR"`
+	SpoolMaxSize          string `env:"SPOOL_MAX_SIZE,default=100Mi"`
+	SpoolMaxAge           string `env:"SPOOL_MAX_AGE,default=24h"`
+	UploadRetryBackoff    string `env:"UPLOAD_RETRY_BACKOFF,default=10s"`
+	UploadRetryMaxBackoff string `env:"UPLOAD_RETRY_MAX_BACKOFF,default=10m"`
+	DeltaUploads          bool   `env:"DELTA_UPLOADS,default=false"`
+	DeltaResyncInterval   string `env:"DELTA_RESYNC_INTERVAL,default=24h"`
+	ReadinessMaxUploadAge string `env:"READINESS_MAX_UPLOAD_AGE,default=3h"`
+	TriggerTokenFile      string `env:"TRIGGER_TOKEN_FILE"`
+	TriggerMinInterval    string `env:"TRIGGER_MIN_INTERVAL,default=1m"`
+	HistorySize           int    `env:"HISTORY_SIZE,default=24"`
+	HistoryDir            string `env:"HISTORY_DIR"`
+	HistoryInterval       string `env:"HISTORY_INTERVAL,default=1h"`
+	WatchBufferSize       int    `env:"WATCH_BUFFER_SIZE,default=10000"`
+	HTTPPort              string `env:"HTTP_PORT,default=8087"`
+	HTTPPortMeta          string `env:"HTTP_PORT_META,default=8088"`
+	TLSCrt                string `env:"TLS_CRT,default=/etc/certificates/tls.crt"`
+	TLSKey                string `env:"TLS_KEY,default=/etc/certificates/tls.key"`
+	AuthEnabled           bool   `env:"AUTH_ENABLED,default=true"`
+	Debug                 bool   `env:"DEBUG,default=false"`
+	Extras                env.EnvSet
 }
 
 func NewConfig() Config {
@@ -19,8 +19,8 @@ type infProviders struct {
 	GCP   bool
 }
 
-func DetectKubernetesProvider(cs *ck.Clientset) string {
-	provider, err := detector.DetectProvider(context.TODO(), cs)
+func DetectKubernetesProvider(ctx context.Context, cs *ck.Clientset) string {
+	provider, err := detector.DetectProvider(ctx, cs)
 	if err != nil {
 		log.Info().Msg("Could not detect cluster provider")
 		provider = "undetected"
@@ -28,7 +28,7 @@ func DetectKubernetesProvider(cs *ck.Clientset) string {
 	return provider
 }
 
-func DetectInfrastructureProvider(cs *ck.Clientset, kubernetesProvider string) string {
+func DetectInfrastructureProvider(ctx context.Context, cs *ck.Clientset, kubernetesProvider string) string {
 	switch kubernetesProvider {
 	case "aks":
 		return "azure"
@@ -66,7 +66,7 @@ func DetectInfrastructureProvider(cs *ck.Clientset, kubernetesProvider string) s
 	}
 
 	log.Debug().Msg("Collecting node information to detect additional cluster information")
-	if nodes, err := cs.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{}); err == nil {
+	if nodes, err := cs.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil {
 		for p := range nodes.Items {
 			node := nodes.Items[p]
 			labels := node.ObjectMeta.GetLabels()
@@ -1,35 +1,41 @@
 package kubernetes
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/go-logr/zerologr"
+	v1 "k8s.io/api/core/v1"
 	ck "k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
-	ctrl "sigs.k8s.io/controller-runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
 	rtlog "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 func CreateK8SClient(impersonate string) (*ck.Clientset, client.Client, error) {
-	var err error
-	var conf *restclient.Config
-	rtlog.SetLogger(zerologr.New(&log.Logger))
+	return CreateK8SClientFromKubeconfig("", "", impersonate)
+}
 
-	conf, err = ctrl.GetConfig()
+// CreateK8SClientFromKubeconfig creates a client for a context in a
+// kubeconfig file. Empty values select the same configuration as
+// CreateK8SClient, i.e. $KUBECONFIG, in-cluster or ~/.kube/config and the
+// current context.
+func CreateK8SClientFromKubeconfig(kubeconfig, kubeContext, impersonate string) (*ck.Clientset, client.Client, error) {
+	conf, err := getConfig(kubeconfig, kubeContext, impersonate)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if impersonate != "" {
-		log.Info().Str("user", impersonate).Msg("impersonating as user")
-		conf.Impersonate = restclient.ImpersonationConfig{UserName: impersonate}
-	}
-
 	clientset, err := ck.NewForConfig(conf)
 	if err != nil {
 		return nil, nil, err
@@ -42,11 +48,178 @@ func CreateK8SClient(impersonate string) (*ck.Clientset, client.Client, error) {
 	return clientset, cl, err
 }
 
-func GetK8SRESTResource(cs *ck.Clientset, path string) (res restclient.Result, found bool, err error) {
+// CreateCachedK8SClient creates a client that reads objects from a shared
+// informer cache. Informers are started lazily the first time a kind is read
+// and are kept up to date from watch events until ctx is cancelled. The cache
+// must sync within syncTimeout. Callers should cancel ctx if an error is
+// returned to stop the informers already started.
+//
+// Reads through the clientset, i.e. discovery and custom resources, are not
+// watched. They are served from responses until it is reset.
+func CreateCachedK8SClient(ctx context.Context, impersonate string, syncTimeout time.Duration, responses *ResponseCache) (*ck.Clientset, client.Client, cache.Cache, error) {
+	conf, err := getConfig("", "", impersonate)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	csConf := restclient.CopyConfig(conf)
+	csConf.Wrap(responses.wrap)
+	clientset, err := ck.NewForConfig(csConf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ca, err := cache.New(conf, cache.Options{DefaultTransform: cache.TransformStripManagedFields()})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	cl, err := client.New(conf, client.Options{
+		Cache: &client.CacheOptions{
+			Reader: unpagedReader{ca},
+			// Only a few of these are ever read, so don't keep all of them in memory
+			DisableFor: []client.Object{&v1.ConfigMap{}, &v1.Secret{}},
+		},
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	go func() {
+		if err := ca.Start(ctx); err != nil {
+			log.Error().Err(err).Msg("starting informer cache")
+		}
+	}()
+	syncCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	if !ca.WaitForCacheSync(syncCtx) {
+		return nil, nil, nil, fmt.Errorf("informer cache did not sync within %v", syncTimeout)
+	}
+
+	return clientset, cl, ca, nil
+}
+
+// ResponseCache keeps the responses to GET requests until it is reset, so
+// objects that are not watched are read once per reset
+type ResponseCache struct {
+	mu        sync.Mutex
+	responses map[string]*cachedResponse
+}
+
+type cachedResponse struct {
+	status string
+	code   int
+	header http.Header
+	body   []byte
+}
+
+func NewResponseCache() *ResponseCache {
+	return &ResponseCache{responses: make(map[string]*cachedResponse)}
+}
+
+// Reset drops all responses kept
+func (c *ResponseCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses = make(map[string]*cachedResponse)
+}
+
+func (c *ResponseCache) wrap(rt http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			return rt.RoundTrip(req)
+		}
+		// Discovery asks for different representations of the same paths
+		key := req.URL.String() + " " + req.Header.Get("Accept")
+		c.mu.Lock()
+		r, found := c.responses[key]
+		c.mu.Unlock()
+		if found {
+			return r.response(req), nil
+		}
+
+		res, err := rt.RoundTrip(req)
+		if err != nil || (res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound) {
+			return res, err
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		r = &cachedResponse{status: res.Status, code: res.StatusCode, header: res.Header, body: body}
+		c.mu.Lock()
+		c.responses[key] = r
+		c.mu.Unlock()
+		return r.response(req), nil
+	})
+}
+
+func (r *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        r.status,
+		StatusCode:    r.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func getConfig(kubeconfig, kubeContext, impersonate string) (*restclient.Config, error) {
+	rtlog.SetLogger(zerologr.New(&log.Logger))
+
+	var (
+		conf *restclient.Config
+		err  error
+	)
+	if kubeconfig != "" {
+		conf, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+		).ClientConfig()
+	} else {
+		conf, err = ctrlconfig.GetConfigWithContext(kubeContext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if impersonate != "" {
+		log.Info().Str("user", impersonate).Msg("impersonating as user")
+		conf.Impersonate = restclient.ImpersonationConfig{UserName: impersonate}
+	}
+
+	return conf, nil
+}
+
+// unpagedReader drops paging options before reading from the cache. The cache
+// does not support continue tokens and silently truncates results when a
+// limit is given, which would break callers written for the API server.
+type unpagedReader struct {
+	client.Reader
+}
+
+func (r unpagedReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	listOpts.Limit = 0
+	listOpts.Continue = ""
+	return r.Reader.List(ctx, list, listOpts)
+}
+
+func GetK8SRESTResource(ctx context.Context, cs *ck.Clientset, path string) (res restclient.Result, found bool, err error) {
 	res = cs.Discovery().RESTClient().
 		Get().
 		AbsPath(path).
-		Do(context.TODO())
+		Do(ctx)
 
 	statusCode := 0
 	res.StatusCode(&statusCode)
@@ -54,6 +227,8 @@ func GetK8SRESTResource(cs *ck.Clientset, path string) (res restclient.Result, f
 		found = true
 	} else if statusCode == http.StatusNotFound {
 		log.Info().Msgf("No %v resources found", path)
+	} else if statusCode == 0 && res.Error() != nil {
+		err = res.Error()
 	} else {
 		err = fmt.Errorf("expected %v, got %v", http.StatusOK, statusCode)
 	}
@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResponseCache(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	count := func(k string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests[k]
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/error":
+			http.Error(w, "error", http.StatusInternalServerError)
+		default:
+			_, _ = io.WriteString(w, r.URL.Path+" "+r.Header.Get("Accept"))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewResponseCache()
+	cl := &http.Client{Transport: c.wrap(http.DefaultTransport)}
+	get := func(method, path, accept string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Accept", accept)
+		res, err := cl.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, string(body)
+	}
+
+	for n := 0; n < 2; n++ {
+		if code, body := get(http.MethodGet, "/apis", "a"); code != http.StatusOK || body != "/apis a" {
+			t.Errorf("got %d %q", code, body)
+		}
+		if _, body := get(http.MethodGet, "/apis", "b"); body != "/apis b" {
+			t.Errorf("got %q", body)
+		}
+		if code, _ := get(http.MethodGet, "/missing", ""); code != http.StatusNotFound {
+			t.Errorf("got %d", code)
+		}
+		if code, body := get(http.MethodGet, "/error", ""); code != http.StatusInternalServerError || !strings.HasPrefix(body, "error") {
+			t.Errorf("got %d %q", code, body)
+		}
+		get(http.MethodPost, "/apis", "a")
+	}
+	want := map[string]int{"GET /apis": 2, "GET /missing": 1, "GET /error": 2, "POST /apis": 2}
+	for k, v := range want {
+		if count(k) != v {
+			t.Errorf("%s: got %d requests, want %d", k, count(k), v)
+		}
+	}
+
+	c.Reset()
+	get(http.MethodGet, "/apis", "a")
+	if n := count("GET /apis"); n != 3 {
+		t.Errorf("got %d requests after reset, want 3", n)
+	}
+}
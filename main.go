@@ -3,11 +3,13 @@ package main
 import (
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 
 	"github.com/neticdk-k8s/k8s-inventory-client/collect"
 	"github.com/neticdk-k8s/k8s-inventory-client/collect/version"
 	"github.com/neticdk-k8s/k8s-inventory-client/config"
 	"github.com/neticdk-k8s/k8s-inventory-client/logging"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
@@ -20,6 +22,11 @@ func main() {
 	if cfg.Debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 		log.Debug().Msg("Debugging mode enabled")
+	}
+	if len(os.Args) > 1 && os.Args[1] == "collect" {
+		os.Exit(runCollect(cfg, os.Args[2:]))
+	}
+	if cfg.Debug {
 		log.Debug().Msg("Starting profiler")
 		defer profile.Start(profile.MemProfile).Stop()
 		go func() {
@@ -36,9 +43,14 @@ func main() {
 	go collection.Collect()
 
 	http.Handle("/", collection)
+	http.Handle("/api/", collection.APIHandler())
 
 	metaHandler := http.NewServeMux()
 	metaHandler.HandleFunc("/", collection.ServeHTTPMeta)
+	metaHandler.Handle("/metrics", promhttp.Handler())
+	metaHandler.HandleFunc("/healthz", collection.ServeHTTPLive)
+	metaHandler.HandleFunc("/readyz", collection.ServeHTTPReady)
+	metaHandler.HandleFunc("/collect", collection.ServeHTTPCollect)
 
 	go func() {
 		log.Info().Str("portMeta", cfg.HTTPPortMeta).Msg("starting metadata server")
@@ -0,0 +1,143 @@
+package manifest
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var extensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// Load reads Kubernetes objects from YAML or JSON manifests. path may be a
+// single manifest, a directory that is searched recursively or a tarball,
+// optionally gzipped. Manifests may hold several documents and lists as
+// written by kubectl get -o yaml.
+func Load(path string) ([]*unstructured.Unstructured, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return loadDir(path)
+	}
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	if isTarball(path) {
+		return loadTarball(r)
+	}
+	return decode(path, r)
+}
+
+func isTarball(path string) bool {
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadDir(dir string) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !extensions[filepath.Ext(path)] {
+			return nil
+		}
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		o, err := decode(path, f)
+		if err != nil {
+			return err
+		}
+		objs = append(objs, o...)
+		return nil
+	})
+	return objs, err
+}
+
+func loadTarball(r *bufio.Reader) ([]*unstructured.Unstructured, error) {
+	var in io.Reader = r
+	if magic, _ := r.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading tarball: %v", err)
+		}
+		defer gz.Close()
+		in = gz
+	}
+
+	var objs []*unstructured.Unstructured
+	tr := tar.NewReader(in)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tarball: %v", err)
+		}
+		if h.Typeflag != tar.TypeReg || !extensions[filepath.Ext(h.Name)] {
+			continue
+		}
+		o, err := decode(h.Name, tr)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, o...)
+	}
+}
+
+// decode returns the objects of a manifest. Lists are flattened and
+// documents without a kind are skipped.
+func decode(name string, r io.Reader) ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	d := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		err := d.Decode(&u.Object)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %v", name, err)
+		}
+		if u.GetKind() == "" {
+			continue
+		}
+		if !u.IsList() {
+			objs = append(objs, u)
+			continue
+		}
+		l, err := u.ToList()
+		if err != nil {
+			return nil, fmt.Errorf("decoding %s: %v", name, err)
+		}
+		for i := range l.Items {
+			objs = append(objs, &l.Items[i])
+		}
+	}
+	log.Debug().Str("manifest", name).Int("objects", len(objs)).Msg("loaded manifest")
+	return objs, nil
+}
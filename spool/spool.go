@@ -0,0 +1,194 @@
+package spool
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const suffix = ".upload"
+
+// Spool is a directory of payloads waiting to be uploaded. Entries are kept in
+// the order they were added and are dropped, oldest first, when the spool
+// grows beyond maxBytes or when they get older than maxAge.
+type Spool struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+}
+
+type Entry struct {
+	Name        string
+	Created     time.Time
+	ContentType string
+	Payload     []byte
+}
+
+type Status struct {
+	Entries int        `json:"entries"`
+	Bytes   int64      `json:"bytes"`
+	Oldest  *time.Time `json:"oldest,omitempty"`
+}
+
+type file struct {
+	name    string
+	created time.Time
+	size    int64
+}
+
+func New(dir string, maxBytes int64, maxAge time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %v", err)
+	}
+	s := &Spool{dir: dir, maxBytes: maxBytes, maxAge: maxAge}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.prune(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put adds a payload to the end of the spool
+func (s *Spool) Put(contentType string, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	name := fmt.Sprintf("%020d%s", now.UnixNano(), suffix)
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating spool entry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	_, _ = w.WriteString(contentType + "\n")
+	_, _ = w.Write(payload)
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing spool entry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing spool entry: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("writing spool entry: %v", err)
+	}
+
+	_, err = s.prune()
+	return err
+}
+
+// Oldest returns the oldest entry or nil if the spool is empty
+func (s *Spool) Oldest() (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := s.prune()
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	f := files[0]
+	data, err := os.ReadFile(filepath.Join(s.dir, f.name))
+	if err != nil {
+		return nil, fmt.Errorf("reading spool entry: %v", err)
+	}
+	contentType, payload, _ := bytes.Cut(data, []byte("\n"))
+	return &Entry{
+		Name:        f.name,
+		Created:     f.created,
+		ContentType: string(contentType),
+		Payload:     payload,
+	}, nil
+}
+
+// Remove deletes an entry, typically after it has been uploaded
+func (s *Spool) Remove(e *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(filepath.Join(s.dir, e.Name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing spool entry: %v", err)
+	}
+	return nil
+}
+
+func (s *Spool) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var st Status
+	files, err := s.list()
+	if err != nil {
+		log.Error().Err(err).Msg("listing spool")
+		return st
+	}
+	for _, f := range files {
+		st.Entries++
+		st.Bytes += f.size
+	}
+	if len(files) > 0 {
+		st.Oldest = &files[0].created
+	}
+	return st
+}
+
+// prune drops entries beyond the age and size limits and returns the
+// remaining ones, oldest first. s.mu must be held.
+func (s *Spool) prune() ([]file, error) {
+	files, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, f := range files {
+		size += f.size
+	}
+	for len(files) > 0 {
+		f := files[0]
+		if time.Since(f.created) <= s.maxAge && size <= s.maxBytes {
+			break
+		}
+		log.Warn().Str("entry", f.name).Time("created", f.created).Msg("dropping spooled upload")
+		if err := os.Remove(filepath.Join(s.dir, f.name)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("removing spool entry: %v", err)
+		}
+		size -= f.size
+		files = files[1:]
+	}
+	return files, nil
+}
+
+func (s *Spool) list() ([]file, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading spool directory: %v", err)
+	}
+	files := make([]file, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		nanos, err := strconv.ParseInt(strings.TrimSuffix(name, suffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{name: name, created: time.Unix(0, nanos), size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
@@ -0,0 +1,126 @@
+package spool
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeEntry writes an entry created at created directly to dir
+func writeEntry(t *testing.T, dir string, created time.Time, payload string) string {
+	t.Helper()
+	name := fmt.Sprintf("%020d%s", created.UnixNano(), suffix)
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("application/json\n"+payload), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		maxBytes int64
+		maxAge   time.Duration
+		entries  []time.Duration
+		kept     []int
+	}{
+		{
+			name:     "within limits",
+			maxBytes: 1000,
+			maxAge:   time.Hour,
+			entries:  []time.Duration{3 * time.Minute, 2 * time.Minute, time.Minute},
+			kept:     []int{0, 1, 2},
+		},
+		{
+			name:     "too old",
+			maxBytes: 1000,
+			maxAge:   time.Hour,
+			entries:  []time.Duration{3 * time.Hour, 2 * time.Hour, time.Minute},
+			kept:     []int{2},
+		},
+		{
+			// Entries are 20 bytes, so only two fit
+			name:     "too large",
+			maxBytes: 45,
+			maxAge:   time.Hour,
+			entries:  []time.Duration{3 * time.Minute, 2 * time.Minute, time.Minute},
+			kept:     []int{1, 2},
+		},
+		{
+			name:     "too small for any",
+			maxBytes: 10,
+			maxAge:   time.Hour,
+			entries:  []time.Duration{2 * time.Minute, time.Minute},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var names []string
+			for _, age := range tt.entries {
+				names = append(names, writeEntry(t, dir, now.Add(-age), "abc"))
+			}
+			// Files not made by the spool are left alone
+			if err := os.WriteFile(filepath.Join(dir, "other"), []byte("x"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := New(dir, tt.maxBytes, tt.maxAge)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files, err := s.list()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(tt.kept) {
+				t.Fatalf("got %d entries, want %d", len(files), len(tt.kept))
+			}
+			for n, k := range tt.kept {
+				if files[n].name != names[k] {
+					t.Errorf("entry %d: got %s, want %s", n, files[n].name, names[k])
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dir, "other")); err != nil {
+				t.Errorf("other file removed: %v", err)
+			}
+		})
+	}
+}
+
+func TestPutOldestRemove(t *testing.T) {
+	s, err := New(t.TempDir(), 1000, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := s.Oldest(); err != nil || e != nil {
+		t.Fatalf("empty spool: got %v, %v", e, err)
+	}
+	for _, p := range []string{"first", "second"} {
+		if err := s.Put("application/json", []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := s.Status(); st.Entries != 2 || st.Oldest == nil {
+		t.Errorf("status: got %+v", st)
+	}
+
+	for _, want := range []string{"first", "second"} {
+		e, err := s.Oldest()
+		if err != nil || e == nil {
+			t.Fatalf("got %v, %v", e, err)
+		}
+		if string(e.Payload) != want || e.ContentType != "application/json" {
+			t.Errorf("got %q %q, want %q", e.ContentType, e.Payload, want)
+		}
+		if err := s.Remove(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if st := s.Status(); st.Entries != 0 || st.Bytes != 0 || st.Oldest != nil {
+		t.Errorf("status: got %+v", st)
+	}
+}